@@ -0,0 +1,172 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"errors"
+	"crypto/x509"
+
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"golang.org/x/term"
+)
+
+// checkCertificates parses the certificate material of a new context, shows what it found and warns about anything off.
+// Material which can't be used at all is rejected, for anything else the user gets to decide.
+func checkCertificates(opts *utils.KubeConfigOptions, answers contextDefinition) bool {
+	now := time.Now()
+	var warnings []string
+
+	// The certificate authority is either new or belongs to the cluster we're reusing
+	var authorityData []byte
+	var err error
+	if answers.Cluster == newEntry {
+		if answers.Authority != "" {
+			authorityData, err = os.ReadFile(answers.Authority)
+		} else if answers.AuthorityData != "" {
+			authorityData, err = decodeInlineCertificate(answers.AuthorityData)
+		}
+	} else {
+		authorityData, err = utils.ClusterCertificateAuthority(opts.Config.Clusters[answers.Cluster])
+	}
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to read the certificate authority",
+		}, err)
+		return false
+	}
+
+	var authorities []*x509.Certificate
+	if authorityData != nil {
+		authorities, err = utils.ParseCertificates(authorityData)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "The certificate authority is not a valid certificate",
+			}, err)
+			return false
+		}
+
+		for _, authority := range authorities {
+			warnings = append(warnings, describeCertificate("Certificate authority", authority, now)...)
+		}
+	}
+
+	// Only new users bring along a client certificate
+	if answers.AuthInfo == newEntry && answers.AuthMethod == authCertificate {
+		certificateData, err := os.ReadFile(answers.Certificate)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to read the client certificate",
+			}, err)
+			return false
+		}
+		keyData, err := os.ReadFile(answers.Key)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to read the client key",
+			}, err)
+			return false
+		}
+
+		certificates, err := utils.ParseCertificates(certificateData)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "The client certificate is not a valid certificate",
+			}, err)
+			return false
+		}
+		if err := utils.CheckKeyPair(certificateData, keyData); err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "The client key does not belong to the client certificate",
+			}, err)
+			return false
+		}
+
+		// The first certificate is the client's own, anything after it are intermediates
+		warnings = append(warnings, describeCertificate("Client certificate", certificates[0], now)...)
+		if len(authorities) > 0 && !utils.IsSignedBy(certificates[0], append(authorities, certificates[1:]...)) {
+			warnings = append(warnings, "The client certificate was not signed by the certificate authority, the cluster will likely reject it.")
+		}
+	}
+
+	if len(warnings) == 0 {
+		return true
+	}
+
+	for _, warning := range warnings {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: warning,
+		}, nil)
+	}
+
+	// Let the user decide whether to continue, nobody is there to ask when running non-interactively
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return true
+	}
+	if !confirmAction("Do you want to add the context anyway?") {
+		logHandler.Handle(logger.ErrUserInterrupt, errors.New("user chose not to add the context"))
+		return false
+	}
+	return true
+}
+
+// describeCertificate shows the certificate and returns a warning if it is expired or about to expire
+func describeCertificate(kind string, certificate *x509.Certificate, now time.Time) []string {
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("%s: %s", kind, utils.DescribeCertificate(certificate)),
+	}, nil)
+
+	if problem := utils.ExpiryProblem(certificate, now); problem != "" {
+		return []string{fmt.Sprintf("%s %q %s.", kind, certificate.Subject.String(), problem)}
+	}
+	return nil
+}
+
+func validateCertificateFile(val interface{}) error {
+	if err := validateFileExists(val); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(val.(string))
+	if err != nil {
+		return err
+	}
+	_, err = utils.ParseCertificates(data)
+	return err
+}
+
+func validateKeyFile(val interface{}) error {
+	if err := validateFileExists(val); err != nil {
+		return err
+	}
+
+	_, err := utils.ReadPEMFile(val.(string), "PRIVATE KEY")
+	return err
+}
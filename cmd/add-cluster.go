@@ -0,0 +1,281 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+	"slices"
+	"strings"
+	"net/url"
+	"encoding/base64"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"golang.org/x/term"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Ways to provide the certificate authority of a cluster
+const (
+	caFile   = "file"
+	caInline = "inline"
+	caSystem = "system"
+)
+
+var caSources = []string{caFile, caInline, caSystem}
+
+// Proxy schemes supported by client-go
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// askForClusterInfo retrieves everything needed to add a new cluster.
+func askForClusterInfo(answers *contextDefinition) bool {
+	fields := []contextField{
+		{
+			Flag:     "server",
+			Value:    &answers.Endpoint,
+			Prompt:   &survey.Input{Message: "Please enter the cluster endpoint:"},
+			Validate: validateServerURL,
+		},
+	}
+
+	if !askForFields(fields) {
+		return false
+	}
+
+	// Only bother people with the advanced options when they need them
+	advanced := answers.ProxyURL != "" || answers.TLSServerName != "" || answers.InsecureSkipTLSVerify || answers.DisableCompression
+	interactive := term.IsTerminal(int(os.Stdin.Fd()))
+	if !advanced && answers.Authority == "" && answers.AuthorityData == "" && !answers.SystemCA && interactive {
+		if confirmAction("Do you need a proxy, a TLS server name, insecure mode or to disable compression?") {
+			fields = []contextField{
+				{
+					Flag:     "proxy-url",
+					Value:    &answers.ProxyURL,
+					Prompt:   &survey.Input{Message: "Please enter the proxy URL (leave empty for none):"},
+					Validate: validateProxyURL,
+				},
+				{
+					Flag:     "tls-server-name",
+					Value:    &answers.TLSServerName,
+					Prompt:   &survey.Input{Message: "Please enter the TLS server name (leave empty to use the endpoint's host name):"},
+				},
+			}
+
+			if !askForFields(fields) {
+				return false
+			}
+
+			answers.InsecureSkipTLSVerify = confirmAction("Do you want to skip TLS verification? Anyone in between could intercept your credentials!")
+			answers.DisableCompression = confirmAction("Do you want to disable response compression?")
+		}
+	} else {
+		fields = []contextField{
+			{Flag: "proxy-url", Value: &answers.ProxyURL, Validate: validateProxyURL, Optional: true},
+			{Flag: "tls-server-name", Value: &answers.TLSServerName, Optional: true},
+		}
+
+		if !askForFields(fields) {
+			return false
+		}
+	}
+
+	// Without verification there's nothing to verify against, client-go refuses the combination as well
+	if answers.InsecureSkipTLSVerify {
+		if answers.Authority != "" || answers.AuthorityData != "" || answers.SystemCA {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "A certificate authority can't be combined with --insecure-skip-tls-verify, please choose either.",
+			}, errors.New("both a certificate authority and insecure mode given"))
+			return false
+		}
+		return true
+	}
+
+	// Derive where the certificate authority comes from out of the flags which were given
+	given := 0
+	for _, set := range []bool{answers.Authority != "", answers.AuthorityData != "", answers.SystemCA} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Please provide only one of --certificate-authority, --certificate-authority-data or --system-ca.",
+		}, errors.New("multiple certificate authority sources given"))
+		return false
+	}
+	caSource := ""
+	if answers.Authority != "" {
+		caSource = caFile
+	} else if answers.AuthorityData != "" {
+		caSource = caInline
+	} else if answers.SystemCA || !interactive {
+		// Scripts without a certificate authority rely on the ones trusted by this machine, like kubectl does
+		caSource = caSystem
+	}
+
+	fields = []contextField{
+		{
+			Flag:     "certificate-authority",
+			Value:    &caSource,
+			Prompt:   &survey.Select{
+				Message: "How do you want to provide the certificate authority?",
+				Options: caSources,
+				Description: func(value string, index int) string {
+					switch value {
+					case caFile:
+						return "location of a PEM file"
+					case caInline:
+						return "paste the PEM into the kubeconfig"
+					default:
+						return "trust the certificate authorities of this machine"
+					}
+				},
+			},
+			Validate: validateOption(caSources),
+		},
+	}
+
+	if !askForFields(fields) {
+		return false
+	}
+
+	switch caSource {
+	case caFile:
+		fields = []contextField{
+			{
+				Flag:     "certificate-authority",
+				Value:    &answers.Authority,
+				Prompt:   &survey.Input{
+					Message: "Please enter the certificate authority location:",
+					Suggest: suggestFiles,
+				},
+				Validate: validateCertificateFile,
+			},
+		}
+	case caInline:
+		fields = []contextField{
+			{
+				Flag:     "certificate-authority-data",
+				Value:    &answers.AuthorityData,
+				Prompt:   &survey.Multiline{Message: "Please paste the certificate authority PEM:"},
+				Validate: validateInlineCertificate,
+			},
+		}
+	default:
+		return true
+	}
+
+	return askForFields(fields)
+}
+
+// buildCluster turns the answers into a cluster entry
+func buildCluster(answers contextDefinition) (*api.Cluster, error) {
+	cluster := &api.Cluster{
+		Server:                answers.Endpoint,
+		CertificateAuthority:  answers.Authority,
+		ProxyURL:              answers.ProxyURL,
+		TLSServerName:         answers.TLSServerName,
+		InsecureSkipTLSVerify: answers.InsecureSkipTLSVerify,
+		DisableCompression:    answers.DisableCompression,
+	}
+
+	if answers.AuthorityData != "" {
+		data, err := decodeInlineCertificate(answers.AuthorityData)
+		if err != nil {
+			return nil, err
+		}
+		cluster.CertificateAuthorityData = data
+	}
+
+	return cluster, nil
+}
+
+// decodeInlineCertificate accepts PEM, or PEM encoded as base64 like it's found in kubeconfig files
+func decodeInlineCertificate(str string) ([]byte, error) {
+	data := []byte(strings.TrimSpace(str) + "\n")
+	if err := utils.ValidatePEM(data, "CERTIFICATE"); err == nil {
+		return data, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+	if err != nil {
+		return nil, errors.New("expected a PEM encoded certificate")
+	}
+	if err := utils.ValidatePEM(decoded, "CERTIFICATE"); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+func validateInlineCertificate(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	_, err := decodeInlineCertificate(str)
+	return err
+}
+
+func validateServerURL(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	if str == "" {
+		return errors.New("a cluster endpoint is required")
+	}
+
+	server, err := url.Parse(str)
+	if err != nil || server.Host == "" || (server.Scheme != "https" && server.Scheme != "http") {
+		return fmt.Errorf("'%s' is not a valid endpoint, expected something like https://cluster.example.com:6443", str)
+	}
+	if server.RawQuery != "" || server.Fragment != "" {
+		return fmt.Errorf("the endpoint can't contain a query or fragment")
+	}
+	return nil
+}
+
+func validateProxyURL(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	// No proxy at all is fine
+	if str == "" {
+		return nil
+	}
+
+	proxy, err := url.Parse(str)
+	if err != nil || proxy.Host == "" {
+		return fmt.Errorf("'%s' is not a valid URL", str)
+	}
+	if !slices.Contains(proxySchemes, proxy.Scheme) {
+		return fmt.Errorf("the proxy URL must use one of %s", strings.Join(proxySchemes, ", "))
+	}
+	return nil
+}
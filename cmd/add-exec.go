@@ -0,0 +1,377 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"errors"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/kballard/go-shellquote"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Supported exec credential plugin API versions, the first one is used by default
+var execAPIVersions = []string{"client.authentication.k8s.io/v1beta1", "client.authentication.k8s.io/v1"}
+
+// Supported exec credential plugin interactive modes, the first one is used by default
+var execInteractiveModes = []string{
+	string(api.IfAvailableExecInteractiveMode),
+	string(api.NeverExecInteractiveMode),
+	string(api.AlwaysExecInteractiveMode),
+}
+
+// Default Azure Kubernetes Service AAD server application ID, shared by all managed AAD clusters
+const aksServerID = "6dae42f8-4368-4678-94ff-3960e28e3630"
+
+// Definition of a well-known exec credential plugin
+type execPreset struct {
+	Description        string
+	Command            string
+	InstallHint        string
+	ProvideClusterInfo bool
+
+	// Information specific to the plugin, prompted for when not given as flags
+	Fields func(answers *contextDefinition) []contextField
+
+	// Optional information which depends on the answers to Fields, e.g. the chosen login mode
+	DependentFields func(answers *contextDefinition) []contextField
+
+	// Arguments and environment passed to the plugin
+	Args func(answers contextDefinition) []string
+	Env  func(answers contextDefinition) []api.ExecEnvVar
+}
+
+var execPresetNames = []string{"aws", "gke", "aks", "custom"}
+
+var execPresets = map[string]execPreset{
+	"aws": {
+		Description: "Amazon EKS using aws eks get-token",
+		Command:     "aws",
+		InstallHint: "The AWS CLI is required to authenticate to this cluster, see https://docs.aws.amazon.com/cli/latest/userguide/getting-started-install.html",
+		Fields: func(answers *contextDefinition) []contextField {
+			return []contextField{
+				{
+					Flag:     "eks-cluster-name",
+					Value:    &answers.EKSClusterName,
+					Prompt:   &survey.Input{Message: "Please enter the name of the EKS cluster:"},
+					Validate: survey.Required,
+				},
+				{
+					Flag:     "aws-region",
+					Value:    &answers.AWSRegion,
+					Prompt:   &survey.Input{Message: "Please enter the AWS region (leave empty for the default region):"},
+					Optional: true,
+				},
+				{
+					Flag:     "aws-profile",
+					Value:    &answers.AWSProfile,
+					Prompt:   &survey.Input{Message: "Please enter the AWS profile (leave empty for the default profile):"},
+					Optional: true,
+				},
+			}
+		},
+		Args: func(answers contextDefinition) []string {
+			args := []string{"eks", "get-token", "--cluster-name", answers.EKSClusterName, "--output", "json"}
+			if answers.AWSRegion != "" {
+				args = append(args, "--region", answers.AWSRegion)
+			}
+			return args
+		},
+		Env: func(answers contextDefinition) []api.ExecEnvVar {
+			if answers.AWSProfile == "" {
+				return nil
+			}
+			return []api.ExecEnvVar{{Name: "AWS_PROFILE", Value: answers.AWSProfile}}
+		},
+	},
+	"gke": {
+		Description:        "Google Kubernetes Engine using gke-gcloud-auth-plugin",
+		Command:            "gke-gcloud-auth-plugin",
+		InstallHint:        "Install gke-gcloud-auth-plugin for use with kubectl by following https://cloud.google.com/kubernetes-engine/docs/how-to/cluster-access-for-kubectl#install_plugin",
+		ProvideClusterInfo: true,
+		Fields: func(answers *contextDefinition) []contextField {
+			return nil
+		},
+		Args: func(answers contextDefinition) []string {
+			return nil
+		},
+		Env: func(answers contextDefinition) []api.ExecEnvVar {
+			return nil
+		},
+	},
+	"aks": {
+		Description: "Azure Kubernetes Service using kubelogin",
+		Command:     "kubelogin",
+		InstallHint: "kubelogin is not installed which is required to connect to AAD enabled cluster, see https://aka.ms/aks/kubelogin",
+		Fields: func(answers *contextDefinition) []contextField {
+			return []contextField{
+				{
+					Flag:     "aks-login",
+					Value:    &answers.AKSLoginMode,
+					Prompt:   &survey.Select{
+						Message: "How do you want kubelogin to log in?",
+						Options: []string{"azurecli", "devicecode", "interactive", "spn", "msi", "workloadidentity"},
+					},
+					Validate: validateOption([]string{"azurecli", "devicecode", "interactive", "spn", "msi", "workloadidentity"}),
+				},
+				{
+					Flag:     "aks-server-id",
+					Value:    &answers.AKSServerID,
+					Prompt:   &survey.Input{Message: "Please enter the AAD server application ID (leave empty for the AKS default):"},
+					Optional: true,
+				},
+			}
+		},
+		DependentFields: func(answers *contextDefinition) []contextField {
+			tenantID := contextField{
+				Flag:   "aks-tenant-id",
+				Value:  &answers.AKSTenantID,
+				Prompt: &survey.Input{Message: "Please enter the AAD tenant ID:"},
+			}
+			clientID := contextField{
+				Flag:   "aks-client-id",
+				Value:  &answers.AKSClientID,
+				Prompt: &survey.Input{Message: "Please enter the AAD client application ID:"},
+			}
+
+			switch answers.AKSLoginMode {
+			case "devicecode", "interactive", "spn":
+				tenantID.Validate = survey.Required
+				clientID.Validate = survey.Required
+				return []contextField{tenantID, clientID}
+			case "msi":
+				// Without a client ID the system-assigned identity is used
+				clientID.Prompt = &survey.Input{Message: "Please enter the client ID of the managed identity (leave empty for the system-assigned identity):"}
+				clientID.Optional = true
+				return []contextField{clientID}
+			case "workloadidentity":
+				// kubelogin falls back to the variables injected into the pod by the workload identity webhook
+				tenantID.Prompt = &survey.Input{Message: "Please enter the AAD tenant ID (leave empty to use $AZURE_TENANT_ID):"}
+				tenantID.Optional = true
+				clientID.Prompt = &survey.Input{Message: "Please enter the AAD client application ID (leave empty to use $AZURE_CLIENT_ID):"}
+				clientID.Optional = true
+				return []contextField{tenantID, clientID}
+			default:
+				// The Azure CLI knows who's logged in already
+				return nil
+			}
+		},
+		Args: func(answers contextDefinition) []string {
+			serverID := answers.AKSServerID
+			if serverID == "" {
+				serverID = aksServerID
+			}
+			args := []string{"get-token", "--login", answers.AKSLoginMode, "--server-id", serverID}
+			if answers.AKSTenantID != "" && answers.AKSLoginMode != "azurecli" && answers.AKSLoginMode != "msi" {
+				args = append(args, "--tenant-id", answers.AKSTenantID)
+			}
+			if answers.AKSClientID != "" && answers.AKSLoginMode != "azurecli" {
+				args = append(args, "--client-id", answers.AKSClientID)
+			}
+			return args
+		},
+		Env: func(answers contextDefinition) []api.ExecEnvVar {
+			return nil
+		},
+	},
+	"custom": {
+		Description: "any other exec credential plugin",
+		Fields: func(answers *contextDefinition) []contextField {
+			return []contextField{
+				{
+					Flag:     "exec-command",
+					Value:    &answers.ExecCommand,
+					Prompt:   &survey.Input{Message: "Please enter the command to execute:"},
+					Validate: survey.Required,
+				},
+				{
+					Flag:     "exec-args",
+					Value:    &answers.ExecArgs,
+					Prompt:   &survey.Input{Message: "Please enter the arguments to pass to the command (leave empty for none):"},
+					Validate: validateShellWords,
+					Optional: true,
+				},
+				{
+					Flag:     "exec-env",
+					Value:    &answers.ExecEnv,
+					Prompt:   &survey.Input{Message: "Please enter the environment variables as KEY=VALUE pairs (leave empty for none):"},
+					Validate: validateEnvironment,
+					Optional: true,
+				},
+				{
+					Flag:     "exec-install-hint",
+					Value:    &answers.ExecInstallHint,
+					Prompt:   &survey.Input{Message: "Please enter a hint shown when the command is missing (leave empty for none):"},
+					Optional: true,
+				},
+			}
+		},
+		Args: func(answers contextDefinition) []string {
+			args, _ := shellquote.Split(answers.ExecArgs)
+			return args
+		},
+		Env: func(answers contextDefinition) []api.ExecEnvVar {
+			return nil
+		},
+	},
+}
+
+func askForExecInfo(answers *contextDefinition) bool {
+	// A command without a preset can only mean a custom plugin
+	if answers.ExecPreset == "" && answers.ExecCommand != "" {
+		answers.ExecPreset = "custom"
+	}
+
+	// Choose a preset first, as it determines what else we need to know
+	var fields = []contextField{
+		{
+			Flag:     "exec-preset",
+			Value:    &answers.ExecPreset,
+			Prompt:   &survey.Select{
+				Message: "Which exec credential plugin do you want to use?",
+				Options: execPresetNames,
+				Description: func(value string, index int) string {
+					return execPresets[value].Description
+				},
+			},
+			Validate: validateOption(execPresetNames),
+		},
+	}
+
+	if !askForFields(fields) {
+		return false
+	}
+
+	// Settings which apply to every plugin, only given as flags as the defaults almost always work
+	fields = append(execPresets[answers.ExecPreset].Fields(answers), []contextField{
+		{Flag: "exec-api-version", Value: &answers.ExecAPIVersion, Validate: validateOption(execAPIVersions), Optional: true},
+		{Flag: "exec-interactive-mode", Value: &answers.ExecInteractiveMode, Validate: validateOption(execInteractiveModes), Optional: true},
+		{Flag: "exec-args", Value: &answers.ExecArgs, Validate: validateShellWords, Optional: true},
+		{Flag: "exec-env", Value: &answers.ExecEnv, Validate: validateEnvironment, Optional: true},
+	}...)
+
+	// Drop the generic fields which the preset already prompts for
+	var uniqueFields []contextField
+	seenFlags := map[string]bool{}
+	for _, field := range fields {
+		if !seenFlags[field.Flag] {
+			uniqueFields = append(uniqueFields, field)
+			seenFlags[field.Flag] = true
+		}
+	}
+
+	if !askForFields(uniqueFields) {
+		return false
+	}
+
+	if dependentFields := execPresets[answers.ExecPreset].DependentFields; dependentFields != nil {
+		return askForFields(dependentFields(answers))
+	}
+	return true
+}
+
+// buildExecConfig turns the exec information into the configuration stored in the kubeconfig
+func buildExecConfig(answers contextDefinition) (*api.ExecConfig, error) {
+	preset, exists := execPresets[answers.ExecPreset]
+	if !exists {
+		return nil, fmt.Errorf("unknown exec credential plugin preset %q", answers.ExecPreset)
+	}
+
+	exec := &api.ExecConfig{
+		Command:            preset.Command,
+		Args:               preset.Args(answers),
+		Env:                preset.Env(answers),
+		APIVersion:         execAPIVersions[0],
+		InstallHint:        preset.InstallHint,
+		ProvideClusterInfo: preset.ProvideClusterInfo,
+		InteractiveMode:    api.ExecInteractiveMode(execInteractiveModes[0]),
+	}
+
+	// Values given by the user take precedence over the preset
+	if answers.ExecCommand != "" {
+		exec.Command = answers.ExecCommand
+	}
+	if answers.ExecInstallHint != "" {
+		exec.InstallHint = answers.ExecInstallHint
+	}
+	if answers.ExecAPIVersion != "" {
+		exec.APIVersion = answers.ExecAPIVersion
+	}
+	if answers.ExecInteractiveMode != "" {
+		exec.InteractiveMode = api.ExecInteractiveMode(answers.ExecInteractiveMode)
+	}
+
+	// Extra arguments are appended to the ones of the preset, the custom preset already uses them as is
+	if answers.ExecPreset != "custom" && answers.ExecArgs != "" {
+		args, err := shellquote.Split(answers.ExecArgs)
+		if err != nil {
+			return nil, err
+		}
+		exec.Args = append(exec.Args, args...)
+	}
+
+	env, err := parseEnvironment(answers.ExecEnv)
+	if err != nil {
+		return nil, err
+	}
+	exec.Env = append(exec.Env, env...)
+
+	return exec, nil
+}
+
+// parseEnvironment parses space separated KEY=VALUE pairs, values can be quoted like in a shell
+func parseEnvironment(value string) ([]api.ExecEnvVar, error) {
+	words, err := shellquote.Split(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var env []api.ExecEnvVar
+	for _, word := range words {
+		name, value, found := strings.Cut(word, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("'%s' is not a KEY=VALUE pair", word)
+		}
+		env = append(env, api.ExecEnvVar{Name: name, Value: value})
+	}
+	return env, nil
+}
+
+func validateShellWords(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	_, err := shellquote.Split(str)
+	return err
+}
+
+func validateEnvironment(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	_, err := parseEnvironment(str)
+	return err
+}
@@ -0,0 +1,126 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"errors"
+	"strings"
+	"net/url"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/oidc"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func oidcFields(answers *contextDefinition) []contextField {
+	return []contextField{
+		{
+			Flag:     "oidc-issuer-url",
+			Value:    &answers.OIDCIssuerURL,
+			Prompt:   &survey.Input{Message: "Please enter the issuer URL of the identity provider:"},
+			Validate: validateIssuerURL,
+		},
+		{
+			Flag:     "oidc-client-id",
+			Value:    &answers.OIDCClientID,
+			Prompt:   &survey.Input{Message: "Please enter the client ID:"},
+			Validate: survey.Required,
+		},
+		{
+			Flag:     "oidc-client-secret",
+			Value:    &answers.OIDCClientSecret,
+			Prompt:   &survey.Password{Message: "Please enter the client secret (leave empty for public clients):"},
+			Optional: true,
+		},
+		{
+			Flag:     "oidc-scopes",
+			Value:    &answers.OIDCScopes,
+			Prompt:   &survey.Input{Message: "Please enter any extra scopes, separated by commas (openid is always requested):"},
+			Optional: true,
+		},
+		{
+			Flag:     "oidc-flow",
+			Value:    &answers.OIDCFlow,
+			Prompt:   &survey.Select{
+				Message: "How do you want to log in?",
+				Options: oidc.Flows,
+				Description: func(value string, index int) string {
+					if value == oidc.FlowDevice {
+						return "enter a code in a browser, also works over SSH"
+					}
+					return "log in through the browser on this machine"
+				},
+			},
+			Validate: validateOption(oidc.Flows),
+		},
+	}
+}
+
+// buildOIDCExecConfig configures the built-in oidc-login command as exec credential plugin
+func buildOIDCExecConfig(answers contextDefinition) *api.ExecConfig {
+	args := []string{
+		"oidc-login",
+		"--issuer-url", answers.OIDCIssuerURL,
+		"--client-id", answers.OIDCClientID,
+		"--flow", answers.OIDCFlow,
+	}
+	if answers.OIDCClientSecret != "" {
+		args = append(args, "--client-secret", answers.OIDCClientSecret)
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range strings.Split(answers.OIDCScopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	args = append(args, "--scopes", strings.Join(scopes, ","))
+
+	return &api.ExecConfig{
+		Command:         "kube-context",
+		Args:            args,
+		APIVersion:      execAPIVersions[0],
+		InstallHint:     "kube-context is required to log in to this cluster, see https://github.com/DB-Vincent/kube-context",
+		InteractiveMode: api.IfAvailableExecInteractiveMode,
+	}
+}
+
+func validateIssuerURL(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	issuer, err := url.Parse(str)
+	if err != nil || issuer.Host == "" {
+		return fmt.Errorf("'%s' is not a valid URL", str)
+	}
+
+	// Plain HTTP is only acceptable for an identity provider running on this machine, e.g. while testing
+	if issuer.Scheme != "https" && !(issuer.Scheme == "http" && isLoopback(issuer.Hostname())) {
+		return fmt.Errorf("the issuer URL must use https")
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -19,20 +19,28 @@
 package cmd
 
 import (
+	"io"
 	"os"
 	"fmt"
 	"errors"
+	"slices"
+	"strings"
 	"path/filepath"
 
+	"github.com/gookit/color"
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/DB-Vincent/kube-context/pkg/utils"
 	"github.com/DB-Vincent/kube-context/pkg/logger"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
-	"k8s.io/client-go/tools/clientcmd"
 	api "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// Argument definition
+var embedCertificates bool
+var tokenFromStdin bool
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
 	Use:   "add",
@@ -40,171 +48,553 @@ var addCmd = &cobra.Command{
 	Run:   runAddCommand,
 }
 
+// Supported ways for a user to authenticate
+const (
+	authCertificate = "certificate"
+	authToken       = "token"
+	authExec        = "exec"
+	authOIDC        = "oidc"
+)
+
+var authMethods = []string{authCertificate, authToken, authExec, authOIDC}
+
+// Option to enter a new cluster or user instead of reusing an existing one
+const newEntry = "<new>"
+
 // Definition of the information we retrieve from the user
 type contextDefinition struct {
 	Name 		string
 	Endpoint 	string
 	Authority	string
+	AuthorityData	string
+	SystemCA	bool
+	Namespace	string
+	AuthMethod	string
+	Cluster		string
+	AuthInfo	string
 	Certificate	string
 	Key 		string
+	Token		string
+	TokenFile	string
+
+	// Advanced cluster information
+	ProxyURL		string
+	TLSServerName		string
+	InsecureSkipTLSVerify	bool
+	DisableCompression	bool
+
+	// Exec credential plugin information
+	ExecPreset		string
+	ExecCommand		string
+	ExecArgs		string
+	ExecEnv			string
+	ExecAPIVersion		string
+	ExecInteractiveMode	string
+	ExecInstallHint		string
+	EKSClusterName		string
+	AWSRegion		string
+	AWSProfile		string
+	AKSLoginMode		string
+	AKSServerID		string
+	AKSTenantID		string
+	AKSClientID		string
+
+	// OIDC information
+	OIDCIssuerURL		string
+	OIDCClientID		string
+	OIDCClientSecret	string
+	OIDCScopes		string
+	OIDCFlow		string
 }
 
+// A single piece of context information, which can be given as a flag or prompted for
+type contextField struct {
+	Flag     string
+	Value    *string
+	Prompt   survey.Prompt
+	Validate survey.Validator
+	Optional bool
+}
+
+// Context information given as flags, anything missing is prompted for
+var contextFlags contextDefinition
+
 // Main logic for add command
 func runAddCommand(cmd *cobra.Command, args []string) {
 	// Initialize configuration struct
 	opts := &utils.KubeConfigOptions{}
 	opts.InitOrCreate(kubeConfigPath)
 
-	// Retrieve the context information from the user
+	// Retrieve the context information from the flags and the user
 	answers := promptForContextInfo(opts)
 	if (contextDefinition{}) == answers {
 		return
 	}
 
+	// Make sure the certificates are usable before they end up in the kubeconfig
+	if !checkCertificates(opts, answers) {
+		return
+	}
+
 	// Write new context to the Kubeconfig file
 	writeConfig(opts, answers)
 }
 
 func promptForContextInfo(opts *utils.KubeConfigOptions) contextDefinition {
-	var answers = contextDefinition{}
+	var answers = contextFlags
+
+	// Read the token from stdin, so it never shows up in the shell history or process list
+	if tokenFromStdin {
+		token, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to read the token from stdin",
+			}, err)
+			return contextDefinition{}
+		}
+		answers.Token = strings.TrimSpace(string(token))
+	}
 
-	// Set up an interactive prompt to select a context
-	var prompt = []*survey.Question{
+	// Derive the authentication method from the flags which were given
+	if answers.AuthMethod == "" {
+		if answers.Token != "" || answers.TokenFile != "" {
+			answers.AuthMethod = authToken
+		} else if answers.ExecPreset != "" || answers.ExecCommand != "" {
+			answers.AuthMethod = authExec
+		} else if answers.OIDCIssuerURL != "" || answers.OIDCClientID != "" {
+			answers.AuthMethod = authOIDC
+		} else if answers.Certificate != "" || answers.Key != "" {
+			answers.AuthMethod = authCertificate
+		}
+	}
+
+	// Information every context needs
+	var fields = []contextField{
 		{
-			Name:     "name",
+			Flag:     "name",
+			Value:    &answers.Name,
 			Prompt:   &survey.Input{Message: "Please enter a name for the context:"},
-			Validate: func (val interface{}) error {
-				str, ok := val.(string)
-				if !ok {
-					return errors.New("input value is not a string")
-				}
-
-				if _, exists := opts.Config.Contexts[str]; exists {
-					return fmt.Errorf("a context with name '%s' already exists", str)
-				}
-				return nil
-			},
+			Validate: validateContextName(opts),
 		},
 		{
-			Name:     "endpoint",
-			Prompt:   &survey.Input{Message: "Please enter the cluster endpoint:"},
-			Validate: survey.Required,
+			Flag:     "namespace",
+			Value:    &answers.Namespace,
+			Prompt:   &survey.Input{Message: "Please enter the default namespace (leave empty for none):"},
+			Optional: true,
 		},
-		{
-			Name:   "authority",
-			Prompt: &survey.Input{
-				Message: "Please enter the certificate authority location:",
-				Suggest: func (toComplete string) []string {
-					files, _ := filepath.Glob(toComplete + "*")
-					return files
-				},
-			},
-			Validate: func (val interface{}) error {
-				str, ok := val.(string)
-				if !ok {
-					return errors.New("input value is not a string")
-				}
+	}
 
-				if _, err := os.Stat(str); errors.Is(err, os.ErrNotExist) {
-					return fmt.Errorf("could not find a file with name '%s'", str)
-				}
-				return nil
-			},
-		},
+	if !askForFields(fields) {
+		return contextDefinition{}
+	}
+
+	// Reuse an existing cluster, unless the flags describe a new one
+	clusters := utils.SortedKeys(opts.Config.Clusters)
+	newCluster := answers.Endpoint != "" || answers.Authority != "" || answers.AuthorityData != "" || answers.SystemCA || answers.ProxyURL != "" ||
+		answers.TLSServerName != "" || answers.InsecureSkipTLSVerify || answers.DisableCompression
+	if !askForExistingEntry("cluster", &answers.Cluster, clusters, newCluster, func(name string) string {
+		return opts.Config.Clusters[name].Server
+	}) {
+		return contextDefinition{}
+	}
+
+	if answers.Cluster == newEntry && !askForClusterInfo(&answers) {
+		return contextDefinition{}
+	}
+
+	// Reuse an existing user, unless the flags describe a new one
+	authInfos := utils.SortedKeys(opts.Config.AuthInfos)
+	if !askForExistingEntry("user", &answers.AuthInfo, authInfos, answers.AuthMethod != "", func(name string) string {
+		return utils.DescribeAuthMethod(opts.Config.AuthInfos[name])
+	}) {
+		return contextDefinition{}
+	}
+
+	if answers.AuthInfo != newEntry {
+		return answers
+	}
+
+	fields = []contextField{
 		{
-			Name:   "certificate",
-			Prompt: &survey.Input{
-				Message: "Please enter the client certificate location:",
-				Suggest: func (toComplete string) []string {
-					files, _ := filepath.Glob(toComplete + "*")
-					return files
+			Flag:     "auth",
+			Value:    &answers.AuthMethod,
+			Prompt:   &survey.Select{
+				Message: "How do you want to authenticate?",
+				Options: authMethods,
+				Description: func(value string, index int) string {
+					return authMethodDescription(value)
 				},
 			},
-			Validate: func (val interface{}) error {
-				str, ok := val.(string)
-				if !ok {
-					return errors.New("input value is not a string")
-				}
+			Validate: validateOption(authMethods),
+		},
+	}
 
-				if _, err := os.Stat(str); errors.Is(err, os.ErrNotExist) {
-					return fmt.Errorf("could not find a file with name '%s'", str)
-				}
-				return nil
+	if !askForFields(fields) {
+		return contextDefinition{}
+	}
+
+	// Information depending on the authentication method
+	switch answers.AuthMethod {
+	case authCertificate:
+		fields = []contextField{
+			{
+				Flag:     "client-certificate",
+				Value:    &answers.Certificate,
+				Prompt:   &survey.Input{
+					Message: "Please enter the client certificate location:",
+					Suggest: suggestFiles,
+				},
+				Validate: validateCertificateFile,
 			},
-		},
-		{
-			Name:   "key",
-			Prompt: &survey.Input{
-				Message: "Please enter the client key location:",
-				Suggest: func (toComplete string) []string {
-					files, _ := filepath.Glob(toComplete + "*")
-					return files
+			{
+				Flag:     "client-key",
+				Value:    &answers.Key,
+				Prompt:   &survey.Input{
+					Message: "Please enter the client key location:",
+					Suggest: suggestFiles,
 				},
+				Validate: validateKeyFile,
 			},
-			Validate: func (val interface{}) error {
-				str, ok := val.(string)
-				if !ok {
-					return errors.New("input value is not a string")
-				}
+		}
+	case authToken:
+		if answers.TokenFile != "" {
+			fields = []contextField{
+				{
+					Flag:     "token-file",
+					Value:    &answers.TokenFile,
+					Validate: validateFileExists,
+				},
+			}
+		} else {
+			// Use a password prompt, so the token is never echoed
+			fields = []contextField{
+				{
+					Flag:     "token",
+					Value:    &answers.Token,
+					Prompt:   &survey.Password{Message: "Please enter the token:"},
+					Validate: survey.Required,
+				},
+			}
+		}
+	case authExec:
+		// Exec plugins need a few rounds of questions, as the preset determines what else we need
+		if !askForExecInfo(&answers) {
+			return contextDefinition{}
+		}
+		return answers
+	case authOIDC:
+		fields = oidcFields(&answers)
+	}
+
+	if !askForFields(fields) {
+		return contextDefinition{}
+	}
+
+	return answers
+}
 
-				if _, err := os.Stat(str); errors.Is(err, os.ErrNotExist) {
-					return fmt.Errorf("could not find a file with name '%s'", str)
+// askForExistingEntry lets the user choose between an existing cluster or user and a new one.
+// We go with a new one when there's nothing to choose from, the flags describe a new one or nobody can answer.
+func askForExistingEntry(flag string, value *string, names []string, describesNew bool, describe func(string) string) bool {
+	if *value != "" && *value != newEntry && describesNew {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("An existing %s can't be combined with flags describing a new one, please choose either.", flag),
+		}, fmt.Errorf("both --%s and new %s information given", flag, flag))
+		return false
+	}
+
+	if *value == "" && (describesNew || len(names) == 0 || !term.IsTerminal(int(os.Stdin.Fd()))) {
+		*value = newEntry
+		return true
+	}
+
+	options := append([]string{newEntry}, names...)
+	field := contextField{
+		Flag:     flag,
+		Value:    value,
+		Prompt:   &survey.Select{
+			Message: fmt.Sprintf("Which %s do you want to use?", flag),
+			Options: options,
+			Description: func(value string, index int) string {
+				if value == newEntry {
+					return "enter a new " + flag
 				}
-				return nil
+				return describe(value)
 			},
 		},
+		Validate: validateOption(options),
+	}
+
+	return askForFields([]contextField{field})
+}
+
+// askForFields validates the values given as flags and prompts for the missing ones.
+// Optional values are only prompted for when we're prompting anyway.
+func askForFields(fields []contextField) bool {
+	var missing []contextField
+	var missingFlags []string
+	for _, field := range fields {
+		if *field.Value == "" {
+			// Some values can only be given as a flag
+			if field.Prompt != nil {
+				missing = append(missing, field)
+			}
+			if !field.Optional {
+				missingFlags = append(missingFlags, "--"+field.Flag)
+			}
+			continue
+		}
+
+		// Validate the values given as flags the same way as prompted values
+		if field.Validate == nil {
+			continue
+		}
+		if err := field.Validate(*field.Value); err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Invalid value for --%s: %s", field.Flag, err),
+			}, err)
+			return false
+		}
+	}
+
+	// Everything required was given as flags, no need to prompt
+	if len(missingFlags) == 0 {
+		return true
+	}
+
+	// Prompts only work when someone is there to answer them
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Missing context information and not running interactively, please provide %s.", strings.Join(missingFlags, ", ")),
+		}, errors.New("stdin is not a terminal"))
+		return false
 	}
 
 	// Prompt the user for information
-	err := survey.Ask(prompt, &answers)
-	if err != nil {
-		if err.Error() == "interrupt" {
-			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted prompt"))
-			return contextDefinition{}
-		} else {
-			logHandler.Handle(logger.ErrPromptFailed, err)
-			return contextDefinition{}
+	for _, field := range missing {
+		var options []survey.AskOpt
+		if field.Validate != nil {
+			options = append(options, survey.WithValidator(field.Validate))
+		}
+
+		err := survey.AskOne(field.Prompt, field.Value, options...)
+		if err != nil {
+			if err.Error() == "interrupt" {
+				logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted prompt"))
+			} else {
+				logHandler.Handle(logger.ErrPromptFailed, err)
+			}
+			return false
 		}
 	}
 
-	return answers
+	return true
 }
 
-func writeConfig(opts *utils.KubeConfigOptions, answers contextDefinition) {
-	// Add information to the internal config struct
-	var cluster api.Cluster
-	cluster.Server = answers.Endpoint
-	cluster.CertificateAuthority = answers.Authority
+func authMethodDescription(method string) string {
+	switch method {
+	case authCertificate:
+		return "client certificate and key"
+	case authToken:
+		return "bearer token"
+	case authExec:
+		return "exec credential plugin, e.g. for EKS, GKE or AKS"
+	case authOIDC:
+		return "OIDC identity provider"
+	default:
+		return ""
+	}
+}
+
+func validateContextName(opts *utils.KubeConfigOptions) survey.Validator {
+	return func (val interface{}) error {
+		str, ok := val.(string)
+		if !ok {
+			return errors.New("input value is not a string")
+		}
+
+		if str == "" {
+			return errors.New("a context name is required")
+		}
 
+		if _, exists := opts.Config.Contexts[str]; exists {
+			return fmt.Errorf("a context with name '%s' already exists", str)
+		}
+		return nil
+	}
+}
+
+func validateFileExists(val interface{}) error {
+	str, ok := val.(string)
+	if !ok {
+		return errors.New("input value is not a string")
+	}
+
+	if _, err := os.Stat(str); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not find a file with name '%s'", str)
+	}
+	return nil
+}
+
+func validateOption(options []string) survey.Validator {
+	return func (val interface{}) error {
+		// Select prompts hand us an option instead of a string
+		if option, ok := val.(survey.OptionAnswer); ok {
+			val = option.Value
+		}
+
+		str, ok := val.(string)
+		if !ok {
+			return errors.New("input value is not a string")
+		}
+
+		if !slices.Contains(options, str) {
+			return fmt.Errorf("'%s' is not one of %s", str, strings.Join(options, ", "))
+		}
+		return nil
+	}
+}
+
+func suggestFiles(toComplete string) []string {
+	files, _ := filepath.Glob(toComplete + "*")
+	return files
+}
+
+func writeConfig(opts *utils.KubeConfigOptions, answers contextDefinition) {
 	var context api.Context
-	context.Cluster = answers.Name
-	context.AuthInfo = answers.Name
+	context.Cluster = answers.Cluster
+	context.AuthInfo = answers.AuthInfo
+	context.Namespace = answers.Namespace
 
-	var auth api.AuthInfo
-	auth.ClientCertificate = answers.Certificate
-	auth.ClientKey = answers.Key
+	// Add a new cluster, named after the context unless that name is taken already
+	if answers.Cluster == newEntry {
+		cluster, err := buildCluster(answers)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to configure the cluster",
+			}, err)
+			return
+		}
+
+		// Store the certificate itself instead of its location
+		if embedCertificates {
+			if _, err := utils.EmbedCluster(cluster); err != nil {
+				logHandler.Handle(logger.ErrorType{
+					Level:   logger.Error,
+					Message: "Failed to embed the certificate authority",
+				}, err)
+				return
+			}
+		}
+
+		context.Cluster = uniqueName(answers.Name, opts.Config.Clusters)
+		opts.Config.Clusters[context.Cluster] = cluster
+	}
+
+	// Add a new user, named after the context unless that name is taken already
+	if answers.AuthInfo == newEntry {
+		var auth api.AuthInfo
+		switch answers.AuthMethod {
+		case authCertificate:
+			auth.ClientCertificate = answers.Certificate
+			auth.ClientKey = answers.Key
+		case authToken:
+			auth.Token = answers.Token
+			auth.TokenFile = answers.TokenFile
+		case authExec:
+			exec, err := buildExecConfig(answers)
+			if err != nil {
+				logHandler.Handle(logger.ErrorType{
+					Level:   logger.Error,
+					Message: "Failed to configure the exec credential plugin",
+				}, err)
+				return
+			}
+			auth.Exec = exec
+		case authOIDC:
+			auth.Exec = buildOIDCExecConfig(answers)
+		}
+
+		// Store the certificate and key themselves instead of their location
+		if embedCertificates {
+			if _, err := utils.EmbedAuthInfo(&auth); err != nil {
+				logHandler.Handle(logger.ErrorType{
+					Level:   logger.Error,
+					Message: "Failed to embed the client certificate and key",
+				}, err)
+				return
+			}
+		}
+
+		context.AuthInfo = uniqueName(answers.Name, opts.Config.AuthInfos)
+		opts.Config.AuthInfos[context.AuthInfo] = &auth
+	}
 
-	opts.Config.Clusters[answers.Name] = &cluster
 	opts.Config.Contexts[answers.Name] = &context
-	opts.Config.AuthInfos[answers.Name] = &auth
 
 	// Write modified configuration to kubeconfig
-	configAccess := clientcmd.NewDefaultPathOptions()
-	if err := clientcmd.ModifyConfig(configAccess, *opts.Config, true); err != nil {
-		logHandler.Handle(logger.ErrorType{
-			Level:   logger.Error,
-			Message: "Failed to write to kubeconfig",
-		}, err)
+	if !saveConfig(opts) {
 		return
 	}
 
 	logHandler.Handle(logger.ErrorType{
 		Level:   logger.Info,
-		Message: fmt.Sprintf("Successfully added context %s!", answers.Name),
+		Message: fmt.Sprintf("Successfully added context %s to %s!", answers.Name, opts.ContextSource(answers.Name)),
 	}, nil)
+
+	// Make sure nobody forgets they just turned off TLS verification
+	if opts.Config.Clusters[context.Cluster].InsecureSkipTLSVerify {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: fmt.Sprintf("TLS verification is DISABLED for cluster %s, anyone between you and the cluster can intercept your credentials and traffic!", color.FgRed.Render(context.Cluster)),
+		}, nil)
+	}
 }
 
 // Cobra command initialization
 func init() {
 	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringVar(&contextFlags.Name, "name", "", "name of the context")
+	addCmd.Flags().StringVar(&contextFlags.Cluster, "cluster", "", "name of an existing cluster to use instead of adding a new one")
+	addCmd.Flags().StringVar(&contextFlags.AuthInfo, "user", "", "name of an existing user to use instead of adding a new one")
+	addCmd.Flags().StringVar(&contextFlags.Endpoint, "server", "", "endpoint of the cluster")
+	addCmd.Flags().StringVar(&contextFlags.Authority, "certificate-authority", "", "location of the certificate authority")
+	addCmd.Flags().StringVar(&contextFlags.AuthorityData, "certificate-authority-data", "", "PEM encoded certificate authority, stored inline in the kubeconfig")
+	addCmd.Flags().BoolVar(&contextFlags.SystemCA, "system-ca", false, "trust the certificate authorities of this machine, the default when no certificate authority is given without a terminal")
+	addCmd.Flags().StringVar(&contextFlags.ProxyURL, "proxy-url", "", "http, https or socks5 proxy used to reach the cluster")
+	addCmd.Flags().StringVar(&contextFlags.TLSServerName, "tls-server-name", "", "server name used to verify the cluster certificate, if it differs from the endpoint's host name")
+	addCmd.Flags().BoolVar(&contextFlags.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "skip verifying the cluster certificate, this is insecure and should only be used for testing")
+	addCmd.Flags().BoolVar(&contextFlags.DisableCompression, "disable-compression", false, "disable response compression for requests to the cluster")
+	addCmd.Flags().StringVarP(&contextFlags.Namespace, "namespace", "n", "", "default namespace of the context")
+	addCmd.Flags().StringVar(&contextFlags.AuthMethod, "auth", "", "authentication method: certificate, token, exec or oidc")
+	addCmd.Flags().StringVar(&contextFlags.Certificate, "client-certificate", "", "location of the client certificate")
+	addCmd.Flags().StringVar(&contextFlags.Key, "client-key", "", "location of the client key")
+	addCmd.Flags().StringVar(&contextFlags.Token, "token", "", "bearer token, prefer --token-file or --token-stdin to keep it out of your shell history")
+	addCmd.Flags().StringVar(&contextFlags.TokenFile, "token-file", "", "location of a file containing the bearer token")
+	addCmd.Flags().BoolVar(&tokenFromStdin, "token-stdin", false, "read the bearer token from stdin")
+	addCmd.Flags().StringVar(&contextFlags.ExecPreset, "exec-preset", "", "exec credential plugin preset: aws, gke, aks or custom")
+	addCmd.Flags().StringVar(&contextFlags.ExecCommand, "exec-command", "", "command of the exec credential plugin")
+	addCmd.Flags().StringVar(&contextFlags.ExecArgs, "exec-args", "", "arguments passed to the exec credential plugin, quoted like in a shell")
+	addCmd.Flags().StringVar(&contextFlags.ExecEnv, "exec-env", "", "space separated KEY=VALUE environment variables passed to the exec credential plugin")
+	addCmd.Flags().StringVar(&contextFlags.ExecAPIVersion, "exec-api-version", "", "API version of the exec credential plugin, defaults to client.authentication.k8s.io/v1beta1")
+	addCmd.Flags().StringVar(&contextFlags.ExecInteractiveMode, "exec-interactive-mode", "", "whether the exec credential plugin needs stdin: IfAvailable, Never or Always")
+	addCmd.Flags().StringVar(&contextFlags.ExecInstallHint, "exec-install-hint", "", "hint shown when the exec credential plugin is not installed")
+	addCmd.Flags().StringVar(&contextFlags.EKSClusterName, "eks-cluster-name", "", "name of the EKS cluster, used by the aws preset")
+	addCmd.Flags().StringVar(&contextFlags.AWSRegion, "aws-region", "", "AWS region of the EKS cluster, used by the aws preset")
+	addCmd.Flags().StringVar(&contextFlags.AWSProfile, "aws-profile", "", "AWS profile to authenticate with, used by the aws preset")
+	addCmd.Flags().StringVar(&contextFlags.AKSLoginMode, "aks-login", "", "kubelogin login mode, used by the aks preset")
+	addCmd.Flags().StringVar(&contextFlags.AKSServerID, "aks-server-id", "", "AAD server application ID, used by the aks preset")
+	addCmd.Flags().StringVar(&contextFlags.AKSTenantID, "aks-tenant-id", "", "AAD tenant ID, used by the aks preset for every login mode except azurecli and msi")
+	addCmd.Flags().StringVar(&contextFlags.AKSClientID, "aks-client-id", "", "AAD client application ID, used by the aks preset for every login mode except azurecli")
+	addCmd.Flags().StringVar(&contextFlags.OIDCIssuerURL, "oidc-issuer-url", "", "issuer URL of the OIDC identity provider")
+	addCmd.Flags().StringVar(&contextFlags.OIDCClientID, "oidc-client-id", "", "OIDC client ID")
+	addCmd.Flags().StringVar(&contextFlags.OIDCClientSecret, "oidc-client-secret", "", "OIDC client secret, if any")
+	addCmd.Flags().StringVar(&contextFlags.OIDCScopes, "oidc-scopes", "", "comma separated extra OIDC scopes, openid is always requested")
+	addCmd.Flags().StringVar(&contextFlags.OIDCFlow, "oidc-flow", "", "OIDC login flow: authcode or device")
+	addCmd.Flags().BoolVar(&embedCertificates, "embed", false, "embed the certificate authority, client certificate and client key into the kubeconfig")
 }
@@ -0,0 +1,116 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"errors"
+	"text/tabwriter"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// Argument definition
+var expiryThreshold int
+
+// certsCmd represents the certs command
+var certsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Reports the expiry of every certificate in your kubeconfig",
+	Long: `Reports the subject, issuer and expiry of every certificate authority and client certificate in your kubeconfig, both file-based and embedded.
+With --threshold, the command exits with a non-zero status when any certificate expires within the given number of days, so it can be run from cron or a login hook.`,
+	Run: runCertsCommand,
+}
+
+// Main logic for certs command
+func runCertsCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	reports := opts.FindCertificates(time.Now())
+	if len(reports) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Your kubeconfig doesn't contain any certificates.",
+		}, nil)
+		return
+	}
+
+	displayCertificateReports(reports)
+
+	// Only fail when asked to, so the report itself can be used without breaking scripts
+	if !cmd.Flags().Changed("threshold") {
+		return
+	}
+
+	expiring := 0
+	for _, report := range reports {
+		if report.Err != nil || report.DaysRemaining < expiryThreshold {
+			expiring++
+		}
+	}
+
+	if expiring > 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("%s certificate(s) are unreadable or expire within %d days!", color.FgRed.Render(expiring), expiryThreshold),
+		}, errors.New("certificates expiring"))
+	}
+}
+
+func displayCertificateReports(reports []utils.CertificateReport) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "KIND\tNAME\tSUBJECT\tISSUER\tNOT AFTER\tDAYS\tSOURCE")
+
+	for _, report := range reports {
+		if report.Err != nil {
+			fmt.Fprintf(writer, "%s\t%s\t%s\t\t\t%s\t%s\n", report.Kind, report.Name, report.Err, color.FgRed.Render("-"), report.Source)
+			continue
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", report.Kind, report.Name, report.Subject, report.Issuer,
+			report.NotAfter.Local().Format(time.DateTime), renderDaysRemaining(report.DaysRemaining), report.Source)
+	}
+
+	writer.Flush()
+}
+
+// renderDaysRemaining colors the days, every value gets a color so the table columns stay aligned
+func renderDaysRemaining(days int) string {
+	switch {
+	case days < 0:
+		return color.FgRed.Render(days)
+	case days < int(utils.CertificateExpiryWarning.Hours() / 24):
+		return color.FgYellow.Render(days)
+	default:
+		return color.FgGreen.Render(days)
+	}
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(certsCmd)
+	certsCmd.Flags().IntVarP(&expiryThreshold, "threshold", "t", 0, "exit with a non-zero status when a certificate expires within this many days")
+}
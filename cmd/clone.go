@@ -0,0 +1,154 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+
+	"github.com/gookit/color"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Argument definition
+var cloneNamespace string
+var cloneAuthInfo string
+var cloneCluster string
+
+// cloneCmd represents the clone command
+var cloneCmd = &cobra.Command{
+	Use:   "clone <source> <new-name>",
+	Short: "Copies a context under a new name",
+	Long:  "Copies a context under a new name, optionally with a different namespace, user or cluster. Missing arguments are prompted for.",
+	Args:  cobra.MaximumNArgs(2),
+	Run:   runCloneCommand,
+}
+
+// Main logic for clone command
+func runCloneCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	source, newName := "", ""
+	if len(args) > 0 {
+		source = args[0]
+	}
+	if len(args) > 1 {
+		newName = args[1]
+	}
+
+	// Find the context to copy
+	if source == "" {
+		promptForContext(opts, &source)
+		if source == "" {
+			return
+		}
+	}
+	sourceContext, exists := opts.Config.Contexts[source]
+	if !exists {
+		logHandler.Handle(logger.ErrContextNotFound, fmt.Errorf("context %s not found", source), opts.Contexts)
+		return
+	}
+
+	// Name the copy, validated the same way as the name of a new context
+	validateName := validateContextName(opts)
+	if newName == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Missing the name of the copy and not running interactively, please provide it as the second argument.",
+			}, errors.New("stdin is not a terminal"))
+			return
+		}
+
+		prompt := &survey.Input{Message: fmt.Sprintf("Please enter a name for the copy of %s:", source)}
+		if err := survey.AskOne(prompt, &newName, survey.WithValidator(validateName)); err != nil {
+			if err.Error() == "interrupt" {
+				logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted clone"))
+			} else {
+				logHandler.Handle(logger.ErrPromptFailed, err)
+			}
+			return
+		}
+	} else if err := validateName(newName); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Invalid name for the copy: %s", err),
+		}, err)
+		return
+	}
+
+	// Overrides need to point at existing entries
+	fields := []contextField{
+		{
+			Flag:     "user",
+			Value:    &cloneAuthInfo,
+			Validate: validateOption(utils.SortedKeys(opts.Config.AuthInfos)),
+			Optional: true,
+		},
+		{
+			Flag:     "cluster",
+			Value:    &cloneCluster,
+			Validate: validateOption(utils.SortedKeys(opts.Config.Clusters)),
+			Optional: true,
+		},
+	}
+	if !askForFields(fields) {
+		return
+	}
+
+	// Copy the context, keeping it in the same file as the original
+	clone := sourceContext.DeepCopy()
+	if cmd.Flags().Changed("namespace") {
+		clone.Namespace = cloneNamespace
+	}
+	if cloneAuthInfo != "" {
+		clone.AuthInfo = cloneAuthInfo
+	}
+	if cloneCluster != "" {
+		clone.Cluster = cloneCluster
+	}
+	opts.Config.Contexts[newName] = clone
+
+	if !saveConfig(opts) {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully cloned %s to %s in %s!", color.FgCyan.Render(source), color.FgCyan.Render(newName), opts.ContextSource(newName)),
+	}, nil)
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().StringVarP(&cloneNamespace, "namespace", "n", "", "namespace of the copy, pass an empty value to clear it")
+	cloneCmd.Flags().StringVar(&cloneAuthInfo, "user", "", "name of an existing user the copy should use")
+	cloneCmd.Flags().StringVar(&cloneCluster, "cluster", "", "name of an existing cluster the copy should use")
+}
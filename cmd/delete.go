@@ -30,10 +30,13 @@ import (
 	"github.com/DB-Vincent/kube-context/pkg/logger"
 	"github.com/spf13/cobra"
 
-	"k8s.io/client-go/tools/clientcmd"
 	api "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// Argument definition
+var cascadeDelete bool
+var assumeYes bool
+
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
 	Use:   "delete",
@@ -45,34 +48,67 @@ var deleteCmd = &cobra.Command{
 func runDeleteCommand(cmd *cobra.Command, args []string) {
 	// Initialize configuration struct
 	opts := &utils.KubeConfigOptions{}
-	opts.Init(kubeConfigPath)
+	opts.Load(kubeConfigPath)
 
-	// Retrieve contexts and set up configAccess so we can write the adjusted configuration
+	// Retrieve contexts
 	opts.GetContexts()
-	configAccess := clientcmd.NewDefaultPathOptions()
 
-	// Prompt the user to select a context to delete
-	contextToDelete := selectContextToDelete(opts)
-	if contextToDelete == "" {
+	// Prompt the user to select a context to delete, or delete every context matching the selector
+	var contextsToDelete []string
+	if contextSelector != "" && context == "" {
+		if !filterContexts(opts) {
+			return
+		}
+		contextsToDelete = opts.Contexts
+	} else if contextToDelete := selectContextToDelete(opts); contextToDelete != "" {
+		contextsToDelete = []string{contextToDelete}
+	} else {
 		return
 	}
 
-	// Remove selected context from kubeconfig
-	deleteContext(opts, contextToDelete)
+	// Find the clusters and users which are only used by the selected contexts
+	var orphanedClusters, orphanedAuthInfos []string
+	if cascadeDelete {
+		orphanedClusters, orphanedAuthInfos = findOrphanedEntries(opts, contextsToDelete)
+	}
+
+	// Deleting more than the one context which was asked for always needs confirmation
+	if cascadeDelete || len(contextsToDelete) > 1 {
+		if !confirmCascadeDelete(contextsToDelete, orphanedClusters, orphanedAuthInfos) {
+			return
+		}
+	}
+
+	// Remove selected contexts from kubeconfig
+	for _, contextToDelete := range contextsToDelete {
+		deleteContext(opts, contextToDelete)
+	}
+	replaceDeletedCurrentContext(opts)
+
+	// Remove the clusters and users which are no longer referenced
+	for _, cluster := range orphanedClusters {
+		delete(opts.Config.Clusters, cluster)
+	}
+	for _, authInfo := range orphanedAuthInfos {
+		delete(opts.Config.AuthInfos, authInfo)
+	}
 
 	// Write modified configuration to kubeconfig file
-	err := clientcmd.ModifyConfig(configAccess, *opts.Config, true)
-	if err != nil {
+	if !saveConfig(opts) {
+		return
+	}
+
+	if len(contextsToDelete) == 1 {
 		logHandler.Handle(logger.ErrorType{
-			Level:   logger.Error,
-			Message: "Error updating kubeconfig file",
-		}, err)
+			Level:   logger.Info,
+			Message: fmt.Sprintf("Successfully deleted context %s!", color.FgCyan.Render(contextsToDelete[0])),
+		}, nil)
 		return
 	}
 
 	logHandler.Handle(logger.ErrorType{
 		Level:   logger.Info,
-		Message: fmt.Sprintf("Successfully deleted context %s!", color.FgCyan.Render(contextToDelete)),
+		Message: fmt.Sprintf("Successfully deleted %s contexts!", color.FgCyan.Render(len(contextsToDelete))),
 	}, nil)
 }
 
@@ -107,35 +143,109 @@ func selectContextToDelete(opts *utils.KubeConfigOptions) string {
 	return context
 }
 
+func findOrphanedEntries(opts *utils.KubeConfigOptions, contextsToDelete []string) ([]string, []string) {
+	var orphanedClusters, orphanedAuthInfos []string
+
+	// References from the contexts which are deleted as well don't count
+	remaining := func(references []string) []string {
+		return slices.DeleteFunc(references, func(name string) bool { return slices.Contains(contextsToDelete, name) })
+	}
+
+	for _, contextToDelete := range contextsToDelete {
+		contextInfo := opts.Config.Contexts[contextToDelete]
+
+		// Only remove the cluster if no other context references it
+		if _, exists := opts.Config.Clusters[contextInfo.Cluster]; exists && !slices.Contains(orphanedClusters, contextInfo.Cluster) {
+			if references := remaining(opts.GetClusterReferences(contextInfo.Cluster)); len(references) == 0 {
+				orphanedClusters = append(orphanedClusters, contextInfo.Cluster)
+			} else {
+				logHandler.Handle(logger.ErrorType{
+					Level:   logger.Info,
+					Message: fmt.Sprintf("Keeping cluster %s, it is still used by %q.", color.FgCyan.Render(contextInfo.Cluster), references),
+				}, nil)
+			}
+		}
+
+		// Only remove the user if no other context references it
+		if _, exists := opts.Config.AuthInfos[contextInfo.AuthInfo]; exists && !slices.Contains(orphanedAuthInfos, contextInfo.AuthInfo) {
+			if references := remaining(opts.GetAuthInfoReferences(contextInfo.AuthInfo)); len(references) == 0 {
+				orphanedAuthInfos = append(orphanedAuthInfos, contextInfo.AuthInfo)
+			} else {
+				logHandler.Handle(logger.ErrorType{
+					Level:   logger.Info,
+					Message: fmt.Sprintf("Keeping user %s, it is still used by %q.", color.FgCyan.Render(contextInfo.AuthInfo), references),
+				}, nil)
+			}
+		}
+	}
+
+	return orphanedClusters, orphanedAuthInfos
+}
+
+func confirmCascadeDelete(contextsToDelete, orphanedClusters, orphanedAuthInfos []string) bool {
+	// List everything that is about to be removed
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: "The following entries will be removed from your kubeconfig:",
+	}, nil)
+
+	for _, contextToDelete := range contextsToDelete {
+		fmt.Printf("- context %s\n", color.FgCyan.Render(contextToDelete))
+	}
+	for _, cluster := range orphanedClusters {
+		fmt.Printf("- cluster %s\n", color.FgCyan.Render(cluster))
+	}
+	for _, authInfo := range orphanedAuthInfos {
+		fmt.Printf("- user %s\n", color.FgCyan.Render(authInfo))
+	}
+
+	if assumeYes {
+		return true
+	}
+
+	return confirmAction("Do you want to continue?")
+}
+
 func deleteContext(opts *utils.KubeConfigOptions, contextToDelete string) {
 	logHandler.Handle(logger.ErrorType{
 		Level:   logger.Info,
-		Message: fmt.Sprintf("Deleting context %s from kubeconfig file..", color.FgCyan.Render(contextToDelete)),
+		Message: fmt.Sprintf("Deleting context %s from %s..", color.FgCyan.Render(contextToDelete), opts.ContextSource(contextToDelete)),
 	}, nil)
 
 	// Remove context from context list in configuration struct
 	delete(opts.Config.Contexts, contextToDelete)
+}
 
-	// Change current context to first context in list if current context is deleted
-	if opts.CurrentContext == contextToDelete {
-		firstContext := getFirstContext(opts.Config.Contexts)
-		logHandler.Handle(logger.ErrorType{
-			Level:   logger.Info,
-			Message: fmt.Sprintf("You're currently using the context you want to delete, I'll switch you to the %s context..", color.FgCyan.Render(firstContext)),
-		}, nil)
-		opts.Config.CurrentContext = firstContext
+// replaceDeletedCurrentContext switches to the first remaining context when the current context was deleted.
+// Call it once after all deletions, so it never picks a context which is about to be deleted as well.
+func replaceDeletedCurrentContext(opts *utils.KubeConfigOptions) {
+	if opts.CurrentContext == "" {
+		return
 	}
+	if _, exists := opts.Config.Contexts[opts.CurrentContext]; exists {
+		return
+	}
+
+	firstContext := getFirstContext(opts.Config.Contexts)
+	opts.Config.CurrentContext = firstContext
+	opts.CurrentContext = firstContext
+	if firstContext == "" {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("You were using a context which got deleted, I'll switch you to the %s context..", color.FgCyan.Render(firstContext)),
+	}, nil)
 }
 
 func getFirstContext(contexts map[string]*api.Context) string {
-	var firstContext string
-
-	// Loop through contexts and return first item
-	for context := range contexts {
-		firstContext = context
-		break
+	// Pick the alphabetically first context, so the choice doesn't depend on map order
+	names := utils.SortedKeys(contexts)
+	if len(names) == 0 {
+		return ""
 	}
-	return firstContext
+	return names[0]
 }
 
 // Cobra command initialization
@@ -143,4 +253,7 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 
 	deleteCmd.Flags().StringVarP(&context, "context", "c", "", "name of context which you want to delete")
+	deleteCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "delete every context whose tags match this selector, e.g. env=dev")
+	deleteCmd.Flags().BoolVar(&cascadeDelete, "cascade", false, "also remove the cluster and user of the context when no other context uses them")
+	deleteCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not ask for confirmation")
 }
@@ -0,0 +1,243 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"time"
+	"strings"
+	"encoding/json"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Argument definition
+var describeOutput string
+
+// Supported output formats
+var describeOutputs = []string{"text", "json", "yaml"}
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe [context]",
+	Short: "Shows the full details of a context",
+	Long: `Shows the cluster, TLS settings, certificate authority, authentication method, namespace, extensions and source file of a context, defaulting to the current context.
+The cluster is never contacted, so any context can be described. Secrets are redacted.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runDescribeCommand,
+}
+
+// Main logic for describe command
+func runDescribeCommand(cmd *cobra.Command, args []string) {
+	if err := validateOption(describeOutputs)(describeOutput); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Invalid value for --output: %s", err),
+		}, err)
+	}
+
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	name := opts.CurrentContext
+	if len(args) == 1 {
+		name = args[0]
+	} else if name == "" {
+		promptForContext(opts, &name)
+		if name == "" {
+			return
+		}
+	}
+
+	description, err := opts.DescribeContext(name, time.Now())
+	if err != nil {
+		logHandler.Handle(logger.ErrContextNotFound, err, opts.Contexts)
+		return
+	}
+
+	switch describeOutput {
+	case "json":
+		data, err := json.MarshalIndent(description, "", "  ")
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Fatal,
+				Message: "Failed to encode the description",
+			}, err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(description)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Fatal,
+				Message: "Failed to encode the description",
+			}, err)
+		}
+		fmt.Print(string(data))
+	default:
+		displayContextDescription(description)
+	}
+}
+
+func displayContextDescription(description *utils.ContextDescription) {
+	current := ""
+	if description.Current {
+		current = " (current)"
+	}
+
+	printField("", "Context", color.FgCyan.Render(description.Name)+current)
+	printField("", "Source", description.Source)
+	printField("", "Namespace", valueOrNone(description.Namespace))
+	if len(description.Tags) > 0 {
+		printField("", "Tags", utils.FormatTags(description.Tags))
+	}
+	displayExtensions("", description.Extensions)
+
+	cluster := description.Cluster
+	fmt.Println()
+	printField("", "Cluster", color.FgCyan.Render(cluster.Name))
+	if cluster.Missing {
+		fmt.Printf("  %s\n", color.FgRed.Render("This cluster does not exist in your kubeconfig!"))
+	} else {
+		printField("  ", "Source", cluster.Source)
+		printField("  ", "Server", cluster.Server)
+		if cluster.InsecureSkipTLSVerify {
+			printField("  ", "TLS", color.FgRed.Render("verification disabled (insecure-skip-tls-verify)"))
+		} else {
+			printField("  ", "TLS", "verified")
+		}
+		if cluster.TLSServerName != "" {
+			printField("  ", "TLS name", cluster.TLSServerName)
+		}
+		if cluster.ProxyURL != "" {
+			printField("  ", "Proxy", cluster.ProxyURL)
+		}
+		if cluster.DisableCompression {
+			printField("  ", "Compression", "disabled")
+		}
+		printField("  ", "CA", describeCertificateSource(cluster.CertificateAuthority))
+		displayCertificateDescriptions(cluster.Certificates)
+		displayExtensions("  ", cluster.Extensions)
+	}
+
+	user := description.User
+	fmt.Println()
+	printField("", "User", color.FgCyan.Render(user.Name))
+	if user.Missing {
+		fmt.Printf("  %s\n", color.FgRed.Render("This user does not exist in your kubeconfig!"))
+		return
+	}
+
+	printField("  ", "Source", user.Source)
+	printField("  ", "Auth", user.AuthMethod)
+	if user.ClientCertificate != "" {
+		printField("  ", "Client cert", describeCertificateSource(user.ClientCertificate))
+		displayCertificateDescriptions(user.Certificates)
+	}
+	if user.ClientKey != "" {
+		printField("  ", "Client key", describeCertificateSource(user.ClientKey))
+	}
+	if user.Token != "" {
+		printField("  ", "Token", user.Token)
+	}
+	if user.TokenFile != "" {
+		printField("  ", "Token file", user.TokenFile)
+	}
+	if user.Username != "" {
+		printField("  ", "Username", user.Username)
+		printField("  ", "Password", user.Password)
+	}
+	if user.Exec != nil {
+		printField("  ", "Command", strings.Join(append([]string{user.Exec.Command}, user.Exec.Args...), " "))
+		for _, env := range user.Exec.Env {
+			printField("  ", "Env", env)
+		}
+		if user.Exec.APIVersion != "" {
+			printField("  ", "API", user.Exec.APIVersion)
+		}
+	}
+	if user.AuthProvider != "" {
+		printField("  ", "Provider", user.AuthProvider)
+	}
+	if user.Impersonate != "" {
+		printField("  ", "Impersonate", user.Impersonate)
+	}
+	displayExtensions("  ", user.Extensions)
+}
+
+func displayCertificateDescriptions(certificates []utils.CertificateDescription) {
+	for _, certificate := range certificates {
+		if certificate.Error != "" {
+			fmt.Printf("    - %s\n", color.FgRed.Render("unreadable: "+certificate.Error))
+			continue
+		}
+
+		expiry := fmt.Sprintf("expires %s, %d days left", certificate.NotAfter.Local().Format(time.DateTime), certificate.DaysRemaining)
+		if certificate.Problem != "" {
+			expiry = color.FgRed.Render(fmt.Sprintf("%s, %s", certificate.NotAfter.Local().Format(time.DateTime), certificate.Problem))
+		}
+		fmt.Printf("    - %s, issued by %s, %s\n", certificate.Subject, certificate.Issuer, expiry)
+	}
+}
+
+func displayExtensions(indent string, extensions map[string]string) {
+	for _, name := range utils.SortedKeys(extensions) {
+		printField(indent, "Extension", name+" = "+extensions[name])
+	}
+}
+
+// printField prints an aligned label and value
+func printField(indent string, label string, value string) {
+	fmt.Printf("%s%-*s %s\n", indent, 14-len(indent), label+":", value)
+}
+
+func describeCertificateSource(source string) string {
+	switch source {
+	case "embedded":
+		return "embedded in the kubeconfig"
+	case "system":
+		return "system trust store"
+	case "":
+		return "none"
+	default:
+		return source
+	}
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(describeCmd)
+	describeCmd.Flags().StringVarP(&describeOutput, "output", "o", "text", "output format: text, json or yaml")
+}
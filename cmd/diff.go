@@ -0,0 +1,127 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compares two kubeconfig files entry by entry",
+	Long: `Compares two kubeconfig files by their contexts, clusters and users rather than by text, and shows which fields changed going from <a> to <b>. Secrets are redacted.
+Like diff, the command exits with status 1 when the files differ.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runDiffCommand,
+}
+
+// Main logic for diff command
+func runDiffCommand(cmd *cobra.Command, args []string) {
+	before, err := clientcmd.LoadFromFile(args[0])
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Failed to load %s", args[0]),
+		}, err)
+		return
+	}
+	after, err := clientcmd.LoadFromFile(args[1])
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Failed to load %s", args[1]),
+		}, err)
+		return
+	}
+
+	differences := utils.CompareConfigs(before, after)
+	currentChanged := before.CurrentContext != after.CurrentContext
+	if len(differences) == 0 && !currentChanged {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Both kubeconfig files have the same contexts, clusters and users.",
+		}, nil)
+		return
+	}
+
+	displayDifferences(differences)
+	if currentChanged {
+		fmt.Printf("Current context: %s\n", renderFieldChange(before.CurrentContext, after.CurrentContext))
+	}
+
+	os.Exit(1)
+}
+
+func displayDifferences(differences []utils.EntryDifference) {
+	for _, section := range []struct {
+		Kind  string
+		Title string
+	}{
+		{"context", "Contexts"},
+		{"cluster", "Clusters"},
+		{"user", "Users"},
+	} {
+		printed := false
+		for _, difference := range differences {
+			if difference.Kind != section.Kind {
+				continue
+			}
+			if !printed {
+				fmt.Printf("%s:\n", section.Title)
+				printed = true
+			}
+
+			switch difference.Change {
+			case utils.EntryAdded:
+				fmt.Println(color.FgGreen.Render("+ " + difference.Name))
+			case utils.EntryRemoved:
+				fmt.Println(color.FgRed.Render("- " + difference.Name))
+			default:
+				fmt.Println(color.FgYellow.Render("~ " + difference.Name))
+				for _, field := range difference.Fields {
+					fmt.Printf("    %s: %s\n", field.Field, renderFieldChange(field.Before, field.After))
+				}
+			}
+		}
+	}
+}
+
+func renderFieldChange(before string, after string) string {
+	if before == "" {
+		before = "(none)"
+	}
+	if after == "" {
+		after = "(none)"
+	}
+	return fmt.Sprintf("%s → %s", color.FgRed.Render(before), color.FgGreen.Render(after))
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
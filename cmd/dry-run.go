@@ -0,0 +1,109 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+)
+
+// Number of unchanged lines shown around every change in a diff
+const diffContext = 3
+
+// saveConfig writes the modified configuration, or shows what would change when running with --dry-run.
+// It returns whether the configuration was written.
+func saveConfig(opts *utils.KubeConfigOptions) bool {
+	if dryRun {
+		showPendingChanges(opts)
+		return false
+	}
+
+	if err := opts.WriteConfig(); err != nil {
+		logHandler.Handle(logger.ErrWriteKubeconfig, err)
+		return false
+	}
+	return true
+}
+
+func showPendingChanges(opts *utils.KubeConfigOptions) {
+	lines, err := opts.PendingChanges()
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to compute the changes",
+		}, err)
+		return
+	}
+
+	if lines == nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Dry run, this wouldn't change your kubeconfig.",
+		}, nil)
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: "Dry run, nothing was written. These changes would be made to your kubeconfig (secrets redacted):",
+	}, nil)
+	printDiff(lines)
+}
+
+// printDiff prints the changed lines in color, with a few unchanged lines around them
+func printDiff(lines []utils.DiffLine) {
+	// Find out which unchanged lines are close enough to a change to be shown
+	visible := make([]bool, len(lines))
+	for i, line := range lines {
+		if line.Operation == utils.DiffKeep {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+			visible[j] = true
+		}
+	}
+
+	skipped := false
+	for i, line := range lines {
+		if !visible[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			fmt.Println(color.FgCyan.Render("..."))
+			skipped = false
+		}
+
+		text := fmt.Sprintf("%c %s", line.Operation, line.Text)
+		switch line.Operation {
+		case utils.DiffRemove:
+			fmt.Println(color.FgRed.Render(text))
+		case utils.DiffAdd:
+			fmt.Println(color.FgGreen.Render(text))
+		default:
+			fmt.Println(text)
+		}
+	}
+	if skipped {
+		fmt.Println(color.FgCyan.Render("..."))
+	}
+}
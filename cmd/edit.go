@@ -0,0 +1,309 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"bytes"
+	"strings"
+	"os/exec"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit [context]",
+	Short: "Edit a context, its cluster and its user in your editor",
+	Long: `Opens the context, its cluster and its user as YAML in $KUBE_EDITOR or $EDITOR. Once the editor exits, the result is validated and written back to your kubeconfig.
+When the result is invalid the editor is opened again with the error on top, close it without changes to give up.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runEditCommand,
+}
+
+// Main logic for edit command
+func runEditCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	name := ""
+	if len(args) == 1 {
+		name = args[0]
+	} else {
+		promptForContext(opts, &name)
+		if name == "" {
+			return
+		}
+	}
+	if _, exists := opts.Config.Contexts[name]; !exists {
+		logHandler.Handle(logger.ErrContextNotFound, fmt.Errorf("context %s not found", name), opts.Contexts)
+		return
+	}
+
+	original, err := renderContextForEdit(opts, name)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to render the context",
+		}, err)
+		return
+	}
+
+	// Keep reopening the editor until the result is valid, or the user gives up by not changing anything
+	content := original
+	var editErr error
+	for {
+		edited, err := launchEditor(editHeader(opts, name, editErr), content)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to run the editor, set $EDITOR to the editor you want to use",
+			}, err)
+			return
+		}
+
+		edited = stripComments(edited)
+		if len(bytes.TrimSpace(edited)) == 0 || bytes.Equal(edited, stripComments(content)) {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Info,
+				Message: "Edit cancelled, no changes made.",
+			}, nil)
+			return
+		}
+
+		newName, err := applyContextEdit(opts, name, edited)
+		if err == nil {
+			name = newName
+			break
+		}
+		editErr = err
+		content = edited
+	}
+
+	if !saveConfig(opts) {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully edited context %s!", color.FgCyan.Render(name)),
+	}, nil)
+}
+
+// renderContextForEdit writes the context, its cluster and its user as a kubeconfig of their own
+func renderContextForEdit(opts *utils.KubeConfigOptions, name string) ([]byte, error) {
+	context := opts.Config.Contexts[name]
+
+	config := api.NewConfig()
+	config.Contexts[name] = context
+	if cluster, exists := opts.Config.Clusters[context.Cluster]; exists {
+		config.Clusters[context.Cluster] = cluster
+	}
+	if authInfo, exists := opts.Config.AuthInfos[context.AuthInfo]; exists {
+		config.AuthInfos[context.AuthInfo] = authInfo
+	}
+
+	return clientcmd.Write(*config)
+}
+
+// editHeader explains what's being edited, and why the previous attempt was rejected
+func editHeader(opts *utils.KubeConfigOptions, name string, editErr error) string {
+	context := opts.Config.Contexts[name]
+
+	lines := []string{
+		fmt.Sprintf("Please edit context %q below, lines starting with '#' are ignored.", name),
+		"Close the editor without changes to cancel.",
+	}
+	if others := otherReferences(opts.GetClusterReferences(context.Cluster), name); len(others) > 0 {
+		lines = append(lines, fmt.Sprintf("Cluster %q is also used by %s.", context.Cluster, strings.Join(others, ", ")))
+	}
+	if others := otherReferences(opts.GetAuthInfoReferences(context.AuthInfo), name); len(others) > 0 {
+		lines = append(lines, fmt.Sprintf("User %q is also used by %s.", context.AuthInfo, strings.Join(others, ", ")))
+	}
+	if editErr != nil {
+		lines = append(lines, "", "The changes could not be saved:")
+		for _, line := range strings.Split(editErr.Error(), "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	var header strings.Builder
+	for _, line := range lines {
+		header.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+	return header.String()
+}
+
+func otherReferences(contexts []string, name string) []string {
+	var others []string
+	for _, context := range contexts {
+		if context != name {
+			others = append(others, context)
+		}
+	}
+	return others
+}
+
+// launchEditor opens the content in the user's editor and returns the result
+func launchEditor(header string, content []byte) ([]byte, error) {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	command, err := shellquote.Split(editor)
+	if err != nil || len(command) == 0 {
+		return nil, fmt.Errorf("invalid editor %q: %w", editor, err)
+	}
+
+	// The file holds credentials, so keep it private
+	file, err := os.CreateTemp("", "kube-context-edit-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(header + string(content)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editorCmd := exec.Command(command[0], append(command[1:], file.Name())...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(file.Name())
+}
+
+// stripComments removes the comment lines, including the header we added
+func stripComments(content []byte) []byte {
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// applyContextEdit validates the edited context, cluster and user and merges them into the configuration.
+// The configuration is left alone when the edit is invalid. It returns the (possibly new) name of the context.
+func applyContextEdit(opts *utils.KubeConfigOptions, name string, edited []byte) (string, error) {
+	config, err := clientcmd.Load(edited)
+	if err != nil {
+		return "", fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	if len(config.Contexts) != 1 {
+		return "", fmt.Errorf("expected exactly one context, found %d", len(config.Contexts))
+	}
+	newName := utils.SortedKeys(config.Contexts)[0]
+	if newName != name {
+		if err := validateContextName(opts)(newName); err != nil {
+			return "", err
+		}
+	}
+
+	// Only the entries we handed out can be changed, anything else would silently overwrite another entry
+	original := opts.Config.Contexts[name]
+	for clusterName := range config.Clusters {
+		if _, exists := opts.Config.Clusters[clusterName]; exists && clusterName != original.Cluster {
+			return "", fmt.Errorf("cluster %q already exists, refer to it from the context instead of defining it here", clusterName)
+		}
+	}
+	for authInfoName := range config.AuthInfos {
+		if _, exists := opts.Config.AuthInfos[authInfoName]; exists && authInfoName != original.AuthInfo {
+			return "", fmt.Errorf("user %q already exists, refer to it from the context instead of defining it here", authInfoName)
+		}
+	}
+
+	// Merge into a copy, so nothing changes unless the result is valid
+	merged := opts.Config.DeepCopy()
+	origin := original.LocationOfOrigin
+
+	context := config.Contexts[newName]
+	context.LocationOfOrigin = origin
+	delete(merged.Contexts, name)
+	merged.Contexts[newName] = context
+	if merged.CurrentContext == name {
+		merged.CurrentContext = newName
+	}
+
+	for clusterName, cluster := range config.Clusters {
+		cluster.LocationOfOrigin = origin
+		if existing, exists := merged.Clusters[clusterName]; exists {
+			cluster.LocationOfOrigin = existing.LocationOfOrigin
+		}
+		merged.Clusters[clusterName] = cluster
+	}
+	for authInfoName, authInfo := range config.AuthInfos {
+		authInfo.LocationOfOrigin = origin
+		if existing, exists := merged.AuthInfos[authInfoName]; exists {
+			authInfo.LocationOfOrigin = existing.LocationOfOrigin
+		}
+		merged.AuthInfos[authInfoName] = authInfo
+	}
+
+	// Make sure the context is usable, the same way kubectl checks it. Relative paths are resolved
+	// against the file they come from on a copy, the merged configuration is written as-is.
+	resolved := merged.DeepCopy()
+	if err := clientcmd.ResolveLocalPaths(resolved); err != nil {
+		return "", err
+	}
+	clientConfig := clientcmd.NewNonInteractiveClientConfig(*resolved, newName, &clientcmd.ConfigOverrides{}, nil)
+	if _, err := clientConfig.ClientConfig(); err != nil {
+		return "", err
+	}
+
+	if newName != name {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("Renaming context %s to %s..", color.FgCyan.Render(name), color.FgCyan.Render(newName)),
+		}, nil)
+	}
+
+	opts.Config = merged
+	return newName, nil
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
@@ -0,0 +1,209 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Argument definition
+var extractDirectory string
+
+// embedCmd represents the embed command
+var embedCmd = &cobra.Command{
+	Use:   "embed [context...]",
+	Short: "Embed certificate and key files into your kubeconfig",
+	Long:  "Reads the certificate authority, client certificate and client key files of the given contexts (or all of them) into the kubeconfig itself, so it keeps working when those files move.",
+	Run:   runEmbedCommand,
+}
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract [context...]",
+	Short: "Extract embedded certificates and keys from your kubeconfig into files",
+	Long:  "Writes the embedded certificate authority, client certificate and client key data of the given contexts (or all of them) to files in a managed directory and references them by path instead.",
+	Run:   runExtractCommand,
+}
+
+// Main logic for embed command
+func runEmbedCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	clusters, authInfos, ok := selectCertificateEntries(opts, args)
+	if !ok {
+		return
+	}
+
+	changed := 0
+	for _, name := range clusters {
+		embedded, err := utils.EmbedCluster(opts.Config.Clusters[name])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to embed the certificate authority of cluster %s", color.FgCyan.Render(name)),
+			}, err)
+			return
+		}
+		if embedded {
+			changed++
+		}
+	}
+
+	for _, name := range authInfos {
+		embedded, err := utils.EmbedAuthInfo(opts.Config.AuthInfos[name])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to embed the client certificate and key of user %s", color.FgCyan.Render(name)),
+			}, err)
+			return
+		}
+		if embedded {
+			changed++
+		}
+	}
+
+	writeCertificateChanges(opts, changed, "Successfully embedded the certificates of %s entries!")
+}
+
+// Main logic for extract command
+func runExtractCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	clusters, authInfos, ok := selectCertificateEntries(opts, args)
+	if !ok {
+		return
+	}
+
+	// The files are written first, so the kubeconfig never references files which don't exist.
+	// They're rolled back when anything fails before the kubeconfig was saved.
+	files := &utils.ManagedFiles{}
+	changed := 0
+	for _, name := range clusters {
+		extracted, err := utils.ExtractCluster(name, opts.Config.Clusters[name], extractDirectory, files)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to extract the certificate authority of cluster %s", color.FgCyan.Render(name)),
+			}, err)
+			rollbackExtractedFiles(files)
+			return
+		}
+		if extracted {
+			changed++
+		}
+	}
+
+	for _, name := range authInfos {
+		extracted, err := utils.ExtractAuthInfo(name, opts.Config.AuthInfos[name], extractDirectory, files)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to extract the client certificate and key of user %s", color.FgCyan.Render(name)),
+			}, err)
+			rollbackExtractedFiles(files)
+			return
+		}
+		if extracted {
+			changed++
+		}
+	}
+
+	if !writeCertificateChanges(opts, changed, "Successfully extracted the certificates of %s entries to %s!", color.FgCyan.Render(extractDirectory)) {
+		rollbackExtractedFiles(files)
+	}
+}
+
+// rollbackExtractedFiles undoes the files written by an extract which didn't make it into the kubeconfig
+func rollbackExtractedFiles(files *utils.ManagedFiles) {
+	if err := files.Rollback(); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: fmt.Sprintf("Failed to clean up the files written to %s", color.FgCyan.Render(extractDirectory)),
+		}, err)
+	}
+}
+
+// selectCertificateEntries returns the clusters and users used by the given contexts, or all of them if no contexts were given
+func selectCertificateEntries(opts *utils.KubeConfigOptions, contexts []string) ([]string, []string, bool) {
+	if len(contexts) == 0 {
+		return utils.SortedKeys(opts.Config.Clusters), utils.SortedKeys(opts.Config.AuthInfos), true
+	}
+
+	var clusters, authInfos []string
+	for _, name := range contexts {
+		contextInfo, exists := opts.Config.Contexts[name]
+		if !exists {
+			opts.GetContexts()
+			logHandler.Handle(logger.ErrContextNotFound, fmt.Errorf("context not found in kubeconfig"), opts.Contexts)
+			return nil, nil, false
+		}
+
+		if _, exists := opts.Config.Clusters[contextInfo.Cluster]; exists {
+			clusters = append(clusters, contextInfo.Cluster)
+		}
+		if _, exists := opts.Config.AuthInfos[contextInfo.AuthInfo]; exists {
+			authInfos = append(authInfos, contextInfo.AuthInfo)
+		}
+	}
+
+	return clusters, authInfos, true
+}
+
+// writeCertificateChanges saves the configuration and reports how many entries changed, the message gets the count
+// followed by the extra arguments. It returns whether the configuration was written.
+func writeCertificateChanges(opts *utils.KubeConfigOptions, changed int, message string, args ...any) bool {
+	if changed == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Nothing to do, no matching certificates or keys were found.",
+		}, nil)
+		return false
+	}
+
+	// Write modified configuration to kubeconfig
+	if !saveConfig(opts) {
+		return false
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf(message, append([]any{color.FgCyan.Render(changed)}, args...)...),
+	}, nil)
+	return true
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(embedCmd)
+	rootCmd.AddCommand(extractCmd)
+	extractCmd.Flags().StringVarP(&extractDirectory, "directory", "d", filepath.Join(clientcmd.RecommendedConfigDir, "kube-context", "certs"), "directory to write the certificates and keys to")
+}
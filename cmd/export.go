@@ -0,0 +1,162 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+
+	"github.com/gookit/color"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Argument definition
+var exportOutput string
+var exportNoCredentials bool
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export [context...]",
+	Short: "Export one or more contexts to a self-contained kubeconfig",
+	Run:   runExportCommand,
+}
+
+// Main logic for export command
+func runExportCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	// If no contexts were given, export the ones matching the selector or prompt the user to select them
+	contexts := args
+	if len(contexts) == 0 && contextSelector != "" {
+		if !filterContexts(opts) {
+			return
+		}
+		contexts = opts.Contexts
+	} else if len(contexts) == 0 {
+		contexts = promptForContextsToExport(opts)
+		if len(contexts) == 0 {
+			return
+		}
+	}
+
+	// Build a kubeconfig holding only the selected contexts
+	config, err := opts.ExtractContexts(contexts, exportNoCredentials)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to export contexts",
+		}, err)
+		return
+	}
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to serialize kubeconfig",
+		}, err)
+		return
+	}
+
+	// Print to stdout, so the output can be piped somewhere else
+	if exportOutput == "" {
+		fmt.Print(string(data))
+		return
+	}
+
+	if dryRun {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("Dry run, nothing was written. This would export %s context(s) to %s.", color.FgCyan.Render(len(contexts)), color.FgCyan.Render(exportOutput)),
+		}, nil)
+		return
+	}
+
+	if err := writePrivateFile(exportOutput, data); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Failed to write %s", exportOutput),
+		}, err)
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully exported %s context(s) to %s!", color.FgCyan.Render(len(contexts)), color.FgCyan.Render(exportOutput)),
+	}, nil)
+}
+
+func promptForContextsToExport(opts *utils.KubeConfigOptions) []string {
+	var selected []string
+
+	// Set up a prompt to interactively select the contexts to export
+	prompt := &survey.MultiSelect{
+		Message: "Choose the contexts to export:",
+		Options: opts.Contexts,
+	}
+
+	err := survey.AskOne(prompt, &selected)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted context export"))
+			os.Exit(0)
+		} else {
+			logHandler.Handle(logger.ErrSelectContext, err)
+		}
+		return nil
+	}
+
+	return selected
+}
+
+// writePrivateFile writes data to a file only readable by the current user, as it most likely contains credentials
+func writePrivateFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Tighten the permissions of files which already existed
+	if err := file.Chmod(0600); err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	return err
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write the kubeconfig to, defaults to stdout")
+	exportCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "export every context whose tags match this selector, e.g. env=prod,team!=payments")
+	exportCmd.Flags().BoolVar(&exportNoCredentials, "no-credentials", false, "leave out all user credentials")
+}
@@ -0,0 +1,304 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+	"reflect"
+	"slices"
+
+	"github.com/gookit/color"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Argument definition
+var importStrategy string
+var importSuffix string
+
+// Ways to resolve a name collision while importing
+const (
+	importPrompt    = "prompt"
+	importRename    = "rename"
+	importSkip      = "skip"
+	importOverwrite = "overwrite"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Merge the contexts, clusters and users of another kubeconfig into your kubeconfig",
+	Args:  cobra.ExactArgs(1),
+	Run:   runImportCommand,
+}
+
+// Overview of what happened to the imported entries
+type importSummary struct {
+	Added       []string
+	Renamed     []string
+	Overwritten []string
+	Skipped     []string
+	Unchanged   []string
+}
+
+// Main logic for import command
+func runImportCommand(cmd *cobra.Command, args []string) {
+	if !slices.Contains([]string{importPrompt, importRename, importSkip, importOverwrite}, importStrategy) {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Unknown strategy %q, please use one of prompt, rename, skip or overwrite.", importStrategy),
+		}, fmt.Errorf("invalid import strategy"))
+		return
+	}
+
+	// Load the kubeconfig to import, resolving relative file paths against its location
+	incoming, err := clientcmd.LoadFromFile(args[0])
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Failed to load kubeconfig file %s", args[0]),
+		}, err)
+		return
+	}
+	if err := clientcmd.ResolveLocalPaths(incoming); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Failed to resolve file paths in %s", args[0]),
+		}, err)
+		return
+	}
+
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.InitOrCreate(kubeConfigPath)
+
+	summary := &importSummary{}
+
+	// Merge clusters and users first, so contexts can follow any rename
+	clusterNames, skippedClusters := mergeEntries("cluster", opts.Config.Clusters, incoming.Clusters, func(cluster *api.Cluster) *string { return &cluster.LocationOfOrigin }, summary)
+	authInfoNames, skippedAuthInfos := mergeEntries("user", opts.Config.AuthInfos, incoming.AuthInfos, func(authInfo *api.AuthInfo) *string { return &authInfo.LocationOfOrigin }, summary)
+
+	for _, name := range utils.SortedKeys(incoming.Contexts) {
+		context := incoming.Contexts[name]
+
+		// The existing cluster or user with the same name differs from the skipped one, so the context would end up pointing at the wrong entry
+		if slices.Contains(skippedClusters, context.Cluster) || slices.Contains(skippedAuthInfos, context.AuthInfo) {
+			delete(incoming.Contexts, name)
+			summary.Skipped = append(summary.Skipped, fmt.Sprintf("context %s (its cluster or user was skipped)", name))
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Warning,
+				Message: fmt.Sprintf("Skipping context %s, as its cluster or user was skipped and the existing one differs.", color.FgCyan.Render(name)),
+			}, nil)
+			continue
+		}
+
+		if newName, renamed := clusterNames[context.Cluster]; renamed {
+			context.Cluster = newName
+		}
+		if newName, renamed := authInfoNames[context.AuthInfo]; renamed {
+			context.AuthInfo = newName
+		}
+	}
+	mergeEntries("context", opts.Config.Contexts, incoming.Contexts, func(context *api.Context) *string { return &context.LocationOfOrigin }, summary)
+
+	// Write modified configuration to kubeconfig
+	if !saveConfig(opts) {
+		return
+	}
+
+	displayImportSummary(summary, args[0])
+}
+
+// mergeEntries copies the incoming entries into the existing ones, resolving name collisions along the way.
+// It returns the entries which were imported under a different name, and the names of the entries which were skipped.
+func mergeEntries[T any](kind string, existing map[string]T, incoming map[string]T, origin func(T) *string, summary *importSummary) (map[string]string, []string) {
+	renames := map[string]string{}
+	var skipped []string
+
+	for _, name := range utils.SortedKeys(incoming) {
+		entry := incoming[name]
+		label := fmt.Sprintf("%s %s", kind, name)
+
+		current, exists := existing[name]
+		if !exists {
+			// New entries end up in the default kubeconfig file
+			*origin(entry) = ""
+			existing[name] = entry
+			summary.Added = append(summary.Added, label)
+			continue
+		}
+
+		// Identical entries don't need any attention, no matter where they are stored
+		if sameEntry(current, entry) {
+			summary.Unchanged = append(summary.Unchanged, label)
+			continue
+		}
+		*origin(entry) = *origin(current)
+
+		// The new name can't be taken by an existing entry, nor by one still to be imported
+		newName := uniqueName(name+importSuffix, existing, incoming)
+
+		switch resolveImportCollision(kind, name, newName) {
+		case importRename:
+			*origin(entry) = ""
+			existing[newName] = entry
+			renames[name] = newName
+			summary.Renamed = append(summary.Renamed, fmt.Sprintf("%s → %s", label, newName))
+		case importOverwrite:
+			// Keep the overwritten entry in the file it was loaded from
+			existing[name] = entry
+			summary.Overwritten = append(summary.Overwritten, label)
+		default:
+			skipped = append(skipped, name)
+			summary.Skipped = append(summary.Skipped, label)
+		}
+	}
+
+	return renames, skipped
+}
+
+// sameEntry compares two entries by content, ignoring where they are stored and how their file paths are written
+func sameEntry(a, b any) bool {
+	return reflect.DeepEqual(comparableEntry(a), comparableEntry(b))
+}
+
+// comparableEntry returns a copy of the entry with its file paths resolved against the file it was loaded from
+func comparableEntry(entry any) any {
+	config := api.NewConfig()
+
+	switch entry := entry.(type) {
+	case *api.Cluster:
+		cluster := entry.DeepCopy()
+		config.Clusters["entry"] = cluster
+		if err := clientcmd.ResolveLocalPaths(config); err != nil {
+			return entry
+		}
+		cluster.LocationOfOrigin = ""
+		return cluster
+	case *api.AuthInfo:
+		authInfo := entry.DeepCopy()
+		config.AuthInfos["entry"] = authInfo
+		if err := clientcmd.ResolveLocalPaths(config); err != nil {
+			return entry
+		}
+		authInfo.LocationOfOrigin = ""
+		return authInfo
+	case *api.Context:
+		context := entry.DeepCopy()
+		context.LocationOfOrigin = ""
+		return context
+	}
+	return entry
+}
+
+func resolveImportCollision(kind, name, newName string) string {
+	if importStrategy != importPrompt {
+		return importStrategy
+	}
+
+	options := []string{
+		fmt.Sprintf("Rename to %s", newName),
+		"Skip",
+		"Overwrite",
+	}
+	actions := []string{importRename, importSkip, importOverwrite}
+
+	// Set up a prompt to interactively resolve the collision
+	prompt := &survey.Select{
+		Message: fmt.Sprintf("A %s named %s already exists, what do you want to do?", kind, name),
+		Options: options,
+	}
+
+	var selected int
+	err := survey.AskOne(prompt, &selected)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted import"))
+			os.Exit(0)
+		} else {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to prompt for %s %s, skipping it", kind, name),
+			}, err)
+		}
+		return importSkip
+	}
+
+	return actions[selected]
+}
+
+// uniqueName returns the given name, or the name with a counter appended when any of the entries already use it
+func uniqueName[T any](name string, entries ...map[string]T) string {
+	candidate := name
+	for i := 2; ; i++ {
+		taken := false
+		for _, existing := range entries {
+			if _, exists := existing[candidate]; exists {
+				taken = true
+			}
+		}
+		if !taken {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+func displayImportSummary(summary *importSummary, file string) {
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully imported %s!", color.FgCyan.Render(file)),
+	}, nil)
+
+	sections := []struct {
+		Title   string
+		Entries []string
+	}{
+		{"Added", summary.Added},
+		{"Renamed", summary.Renamed},
+		{"Overwritten", summary.Overwritten},
+		{"Skipped", summary.Skipped},
+		{"Unchanged", summary.Unchanged},
+	}
+
+	for _, section := range sections {
+		if len(section.Entries) == 0 {
+			continue
+		}
+
+		fmt.Printf("%s (%s):\n", section.Title, color.FgCyan.Render(len(section.Entries)))
+		for _, entry := range section.Entries {
+			fmt.Printf("- %s\n", entry)
+		}
+	}
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importStrategy, "strategy", "s", importPrompt, "how to handle name collisions: prompt, rename, skip or overwrite")
+	importCmd.Flags().StringVar(&importSuffix, "suffix", "-imported", "suffix added to the name of renamed entries")
+}
@@ -0,0 +1,159 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"strconv"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// journalCmd represents the journal command
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Lists the changes kube-context made to your kubeconfig",
+	Run:   runJournalCommand,
+}
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo [operation-id]",
+	Short: "Reverts the most recent change, or the given one from the journal",
+	Long:  "Reverts a change kube-context made to your kubeconfig. Only the entries touched by that change are reverted, and only if nothing else changed them since, so changes made by other tools are left alone.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runUndoCommand,
+}
+
+// Main logic for journal command
+func runJournalCommand(cmd *cobra.Command, args []string) {
+	entries := readJournal()
+	if entries == nil {
+		return
+	}
+
+	// Newest first, that's what people are usually looking for
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		line := fmt.Sprintf("- %s %s %s: %s", color.FgCyan.Render(entry.ID), entry.Time.Local().Format(time.DateTime), entry.Command, entry.Summary())
+		if entry.Undone {
+			line += color.FgGray.Render(" (undone)")
+		}
+		fmt.Println(line)
+	}
+}
+
+// Main logic for undo command
+func runUndoCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	entries := readJournal()
+	if entries == nil {
+		return
+	}
+
+	// Find the operation to undo, either from the arguments or the most recent one
+	var entry *utils.JournalEntry
+	if len(args) == 1 {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("%q is not an operation ID, use the journal command to find one", args[0]),
+			}, err)
+			return
+		}
+		for i := range entries {
+			if entries[i].ID == id {
+				entry = &entries[i]
+			}
+		}
+	} else {
+		// Undo walks back through history, so skip undos and whatever was undone already
+		for i := len(entries) - 1; i >= 0 && entry == nil; i-- {
+			if !entries[i].Undone && entries[i].Command != cmd.Name() {
+				entry = &entries[i]
+			}
+		}
+	}
+
+	if entry == nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Could not find an operation to undo, use the journal command to see what can be undone",
+		}, errors.New("no operation to undo"))
+		return
+	}
+
+	if !assumeYes && !confirmAction(fmt.Sprintf("Undo %s %d (%s)?", entry.Command, entry.ID, entry.Summary())) {
+		logHandler.Handle(logger.ErrUserInterrupt, errors.New("user cancelled undo"))
+		return
+	}
+
+	if _, err := opts.UndoJournalEntry(entry.ID); errors.Is(err, utils.ErrDryRun) {
+		showPendingChanges(opts)
+		return
+	} else if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Failed to undo operation %d: %s", entry.ID, err),
+		}, err)
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully undid %s %s!", entry.Command, color.FgCyan.Render(entry.ID)),
+	}, nil)
+}
+
+// readJournal reads the journal, returning nil if there's nothing in it
+func readJournal() []utils.JournalEntry {
+	entries, err := utils.ReadJournal()
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to read the journal",
+		}, err)
+		return nil
+	}
+
+	if len(entries) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "The journal is empty, kube-context hasn't changed anything yet.",
+		}, nil)
+		return nil
+	}
+	return entries
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(journalCmd)
+	rootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not ask for confirmation")
+}
@@ -0,0 +1,127 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+	"encoding/json"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// Argument definition
+var lintOutput string
+
+// Supported output formats
+var lintOutputs = []string{"text", "json"}
+
+// lintCmd represents the lint command
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Checks your kubeconfig for health and security problems",
+	Long: `Checks your kubeconfig for file permissions which are too open, clusters skipping TLS verification or using plain HTTP, static tokens and passwords, deprecated auth-providers, duplicate servers and contexts without a namespace.
+The command exits with a non-zero status when it finds anything, so it can be used to enforce hygiene.`,
+	Run: runLintCommand,
+}
+
+// Main logic for lint command
+func runLintCommand(cmd *cobra.Command, args []string) {
+	if err := validateOption(lintOutputs)(lintOutput); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Invalid value for --output: %s", err),
+		}, err)
+	}
+
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	findings := opts.Lint()
+
+	if lintOutput == "json" {
+		// Always print a list, so scripts don't need to handle null
+		if findings == nil {
+			findings = []utils.LintFinding{}
+		}
+
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Fatal,
+				Message: "Failed to encode the findings",
+			}, err)
+		}
+		fmt.Println(string(data))
+
+		if len(findings) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(findings) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Your kubeconfig is looking healthy, no findings!",
+		}, nil)
+		return
+	}
+
+	displayLintReport(findings)
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Fatal,
+		Message: fmt.Sprintf("Found %s problem(s) in your kubeconfig.", color.FgRed.Render(len(findings))),
+	}, errors.New("lint findings"))
+}
+
+func displayLintReport(findings []utils.LintFinding) {
+	for _, rule := range utils.LintRules {
+		var messages []string
+		for _, finding := range findings {
+			if finding.Rule == rule {
+				messages = append(messages, finding.Message)
+			}
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: fmt.Sprintf("%s (%s):", rule.Description(), color.FgCyan.Render(len(messages))),
+		}, nil)
+		for _, message := range messages {
+			fmt.Printf("- %s\n", message)
+		}
+	}
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "text", "output format: text or json")
+}
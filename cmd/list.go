@@ -38,22 +38,62 @@ var listCmd = &cobra.Command{
 func runListCommand(cmd *cobra.Command, args []string) {
 	// Initialize configuration struct
 	opts := &utils.KubeConfigOptions{}
-	opts.Init(kubeConfigPath)
+	opts.Load(kubeConfigPath)
 
 	// Retrieve contexts
 	opts.GetContexts()
 
-	logHandler.Handle(logger.ErrorType{
-		Level:   logger.Info,
-		Message: fmt.Sprintf("You currently have %s context(s) configured:", color.FgCyan.Render(len(opts.Contexts))),
-	}, nil)
+	if contextSelector != "" {
+		if !filterContexts(opts) {
+			return
+		}
+
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("%s context(s) match selector %s:", color.FgCyan.Render(len(opts.Contexts)), color.FgCyan.Render(contextSelector)),
+		}, nil)
+	} else {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("You currently have %s context(s) configured:", color.FgCyan.Render(len(opts.Contexts))),
+		}, nil)
+	}
+
+	// Only mention source files when the configuration is spread over multiple files
+	multiFile := opts.IsMultiFile()
 
 	for _, context := range opts.Contexts {
-		fmt.Printf("- %s\n", color.FgCyan.Render(context))
+		// Show the tags of a context next to its name
+		tags := ""
+		if contextTags, _ := utils.GetTags(opts.Config.Contexts[context]); len(contextTags) > 0 {
+			tags = " " + color.FgGray.Render("["+utils.FormatTags(contextTags)+"]")
+		}
+
+		if !multiFile {
+			fmt.Printf("- %s%s\n", color.FgCyan.Render(context), tags)
+			continue
+		}
+
+		contextSource := opts.ContextSource(context)
+		fmt.Printf("- %s%s (%s)\n", color.FgCyan.Render(context), tags, contextSource)
+
+		// Point out clusters and users living in a different file than the context referencing them
+		contextInfo := opts.Config.Contexts[context]
+		if _, exists := opts.Config.Clusters[contextInfo.Cluster]; exists {
+			if clusterSource := opts.ClusterSource(contextInfo.Cluster); clusterSource != contextSource {
+				fmt.Printf("    cluster %s from %s\n", color.FgCyan.Render(contextInfo.Cluster), clusterSource)
+			}
+		}
+		if _, exists := opts.Config.AuthInfos[contextInfo.AuthInfo]; exists {
+			if authInfoSource := opts.AuthInfoSource(contextInfo.AuthInfo); authInfoSource != contextSource {
+				fmt.Printf("    user %s from %s\n", color.FgCyan.Render(contextInfo.AuthInfo), authInfoSource)
+			}
+		}
 	}
 }
 
 // Cobra command initialization
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "only list contexts whose tags match this selector, e.g. env=prod,team!=payments")
 }
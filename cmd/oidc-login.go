@@ -0,0 +1,98 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"slices"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/DB-Vincent/kube-context/pkg/oidc"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Argument definition
+var oidcConfig = oidc.Config{Output: os.Stderr}
+var oidcNoBrowser bool
+
+// oidcLoginCmd represents the oidc-login command
+var oidcLoginCmd = &cobra.Command{
+	Use:   "oidc-login",
+	Short: "Log in to an OIDC identity provider, used as exec credential plugin",
+	Long: `Logs in to an OIDC identity provider and prints the ID token as ExecCredential, so kubectl can use it to authenticate.
+Tokens are cached and refreshed automatically, you only need to log in again once the refresh token expires.
+This command is used by contexts created with "kube-context add --auth oidc" and is not meant to be run by hand.`,
+	Run: runOIDCLoginCommand,
+}
+
+// Main logic for oidc-login command
+func runOIDCLoginCommand(cmd *cobra.Command, args []string) {
+	// The ID token is only handed out when the openid scope is requested
+	if !slices.Contains(oidcConfig.Scopes, "openid") {
+		oidcConfig.Scopes = append([]string{"openid"}, oidcConfig.Scopes...)
+	}
+	oidcConfig.OpenBrowser = !oidcNoBrowser
+
+	// Stop waiting for the login when the user presses ctrl+c
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	token, err := oidc.Login(ctx, oidcConfig)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Failed to log in to %s: %s", oidcConfig.IssuerURL, err),
+		}, err)
+		return
+	}
+
+	credential, err := oidc.ExecCredential(token)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: "Failed to create the ExecCredential",
+		}, err)
+		return
+	}
+
+	// kubectl reads the credential from stdout, so nothing else may be printed there
+	fmt.Println(string(credential))
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(oidcLoginCmd)
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.IssuerURL, "issuer-url", "", "URL of the OIDC identity provider")
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.ClientID, "client-id", "", "client ID registered at the identity provider")
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.ClientSecret, "client-secret", "", "client secret registered at the identity provider, if any")
+	oidcLoginCmd.Flags().StringSliceVar(&oidcConfig.Scopes, "scopes", []string{"openid"}, "scopes to request")
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.Flow, "flow", oidc.FlowAuthCode, "login flow: authcode or device")
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.ListenAddress, "listen-address", "127.0.0.1:0", "address of the local callback server used by the authcode flow")
+	oidcLoginCmd.Flags().StringVar(&oidcConfig.CacheDir, "cache-dir", filepath.Join(clientcmd.RecommendedConfigDir, "cache", "kube-context", "oidc"), "directory to cache tokens in")
+	oidcLoginCmd.Flags().BoolVar(&oidcNoBrowser, "no-browser", false, "only print the login URL instead of opening a browser")
+	oidcLoginCmd.Flags().DurationVar(&oidcConfig.Timeout, "timeout", 5*time.Minute, "how long to wait for the login to complete")
+	oidcLoginCmd.MarkFlagRequired("issuer-url")
+	oidcLoginCmd.MarkFlagRequired("client-id")
+}
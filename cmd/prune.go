@@ -0,0 +1,185 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"errors"
+
+	"github.com/gookit/color"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Find and clean up dangling entries in your kubeconfig",
+	Run:   runPruneCommand,
+}
+
+// Main logic for prune command
+func runPruneCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	// Scan the configuration for broken state
+	findings := opts.FindPrunableEntries()
+	if len(findings) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: "Your kubeconfig is looking clean, nothing to prune!",
+		}, nil)
+		return
+	}
+
+	// Show a categorized report of the findings
+	displayPruneReport(findings)
+
+	// Let the user pick which findings to clean up
+	selectedFindings := selectFindingsToPrune(findings)
+	if len(selectedFindings) == 0 {
+		return
+	}
+
+	for _, finding := range selectedFindings {
+		pruneEntry(opts, finding)
+	}
+	replaceDeletedCurrentContext(opts)
+
+	// Write modified configuration to kubeconfig file
+	if !saveConfig(opts) {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully cleaned up %s entries!", color.FgCyan.Render(len(selectedFindings))),
+	}, nil)
+}
+
+func displayPruneReport(findings []utils.PruneFinding) {
+	for _, category := range utils.PruneCategories {
+		var messages []string
+		for _, finding := range findings {
+			if finding.Category == category {
+				messages = append(messages, finding.Message)
+			}
+		}
+
+		if len(messages) == 0 {
+			continue
+		}
+
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: fmt.Sprintf("%s (%s):", category, color.FgCyan.Render(len(messages))),
+		}, nil)
+		for _, message := range messages {
+			fmt.Printf("- %s\n", message)
+		}
+	}
+}
+
+func selectFindingsToPrune(findings []utils.PruneFinding) []utils.PruneFinding {
+	// Clean up everything when confirmation was skipped
+	if assumeYes {
+		return findings
+	}
+
+	options := make([]string, len(findings))
+	for i, finding := range findings {
+		options[i] = finding.Message
+	}
+
+	// Set up a prompt to interactively select the findings to clean up. Everything is selected by default,
+	// except missing certificate authorities, as dropping those changes which servers are trusted.
+	var defaults []string
+	for _, finding := range findings {
+		if finding.Category != utils.MissingFile || finding.Kind != "cluster" {
+			defaults = append(defaults, finding.Message)
+		}
+	}
+	prompt := &survey.MultiSelect{
+		Message: "Choose the entries you want to clean up:",
+		Options: options,
+		Default: defaults,
+	}
+
+	var selected []int
+	err := survey.AskOne(prompt, &selected)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted prune selection"))
+			os.Exit(0)
+		} else {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to prompt for entries to clean up",
+			}, err)
+		}
+		return nil
+	}
+
+	selectedFindings := make([]utils.PruneFinding, 0, len(selected))
+	for _, index := range selected {
+		selectedFindings = append(selectedFindings, findings[index])
+	}
+	return selectedFindings
+}
+
+func pruneEntry(opts *utils.KubeConfigOptions, finding utils.PruneFinding) {
+	switch finding.Category {
+	case utils.DanglingContext:
+		deleteContext(opts, finding.Name)
+	case utils.UnusedCluster:
+		delete(opts.Config.Clusters, finding.Name)
+	case utils.UnusedAuthInfo:
+		delete(opts.Config.AuthInfos, finding.Name)
+	case utils.MissingFile:
+		// Drop the reference to the missing file, unless the whole entry was already removed
+		if cluster, exists := opts.Config.Clusters[finding.Name]; exists && finding.Kind == "cluster" {
+			cluster.CertificateAuthority = ""
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Warning,
+				Message: fmt.Sprintf("Cluster %s no longer has a certificate authority, its server certificate is now verified against the system's trusted certificate authorities.", color.FgCyan.Render(finding.Name)),
+			}, nil)
+		}
+		if authInfo, exists := opts.Config.AuthInfos[finding.Name]; exists && finding.Kind == "user" {
+			// A client certificate is useless without its key and the other way around, so drop the pair
+			authInfo.ClientCertificate = ""
+			authInfo.ClientCertificateData = nil
+			authInfo.ClientKey = ""
+			authInfo.ClientKeyData = nil
+		}
+	}
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "clean up all findings without asking for confirmation")
+}
@@ -29,12 +29,14 @@ import (
 	"github.com/DB-Vincent/kube-context/pkg/utils"
 	"github.com/DB-Vincent/kube-context/pkg/logger"
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/tools/clientcmd"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
 )
 
 // Argument definition
 var contextFrom string
 var contextTo string
+var renameEntries bool
 
 // renameCmd represents the rename command
 var renameCmd = &cobra.Command{
@@ -47,24 +49,24 @@ var renameCmd = &cobra.Command{
 func runRenameCommand(cmd *cobra.Command, args []string) {
 	// Initialize configuration struct
 	opts := &utils.KubeConfigOptions{}
-	opts.Init(kubeConfigPath)
+	opts.Load(kubeConfigPath)
 
-	// Retrieve contexts and set up configAccess so we can write the adjusted configuration
+	// Retrieve contexts
 	opts.GetContexts()
-	configAccess := clientcmd.NewDefaultPathOptions()
 
 	// Retrieve context inputs
-	validateAndSetContextNames(opts)
+	if !validateAndSetContextNames(opts) {
+		return
+	}
 
 	// Rename context
-	renameContext(opts, configAccess)
+	renameContext(opts)
 }
 
-func validateAndSetContextNames(opts *utils.KubeConfigOptions) {
+func validateAndSetContextNames(opts *utils.KubeConfigOptions) bool {
 	// No contexts were given as argument
 	if contextFrom == "" && contextTo == "" {
 		promptContextNames(opts)
-		return
 	}
 
 	if contextFrom == "" || contextTo == "" { // Either "from" or "to" was given, but not both
@@ -72,7 +74,7 @@ func validateAndSetContextNames(opts *utils.KubeConfigOptions) {
 			Level:   logger.Error,
 			Message: "Please enter both the name of the context you want to rename and the new name of the context. Use `kube-context rename --help` for more information.",
 		}, fmt.Errorf("missing context names"))
-		return
+		return false
 	}
 
 	// Verify that context to rename exists in kubeconfig
@@ -81,7 +83,7 @@ func validateAndSetContextNames(opts *utils.KubeConfigOptions) {
 			Level:   logger.Error,
 			Message: fmt.Sprintf("Could not find the \"from\" context in kubeconfig file! Found the following contexts: %q", opts.Contexts),
 		}, fmt.Errorf("context not found in kubeconfig"))
-		return
+		return false
 	}
 
 	// Verify that new name of context doesn't exist in kubeconfig
@@ -91,8 +93,10 @@ func validateAndSetContextNames(opts *utils.KubeConfigOptions) {
 			Level:   logger.Error,
 			Message: "There's already a context with that name. Please give me a different name.",
 		}, fmt.Errorf("new context name already exists"))
-		return
+		return false
 	}
+
+	return true
 }
 
 func promptContextNames(opts *utils.KubeConfigOptions) {
@@ -136,10 +140,10 @@ func promptContextNames(opts *utils.KubeConfigOptions) {
 	contextTo = answers.NewContext
 }
 
-func renameContext(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigAccess) {
+func renameContext(opts *utils.KubeConfigOptions) {
 	logHandler.Handle(logger.ErrorType{
 		Level:   logger.Info,
-		Message: fmt.Sprintf("Renaming %s context to %s..", color.FgCyan.Render(contextFrom), color.FgCyan.Render(contextTo)),
+		Message: fmt.Sprintf("Renaming %s context to %s in %s..", color.FgCyan.Render(contextFrom), color.FgCyan.Render(contextTo), opts.ContextSource(contextFrom)),
 	}, nil)
 
 	// Get given context
@@ -156,10 +160,17 @@ func renameContext(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigA
 		opts.Config.CurrentContext = contextTo
 	}
 
+	// Rename the cluster and user of the context along with it
+	if err := renameContextEntries(opts, context); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Could not rename the cluster and user of the context: %s", err),
+		}, err)
+		return
+	}
+
 	// Modify the kubeconfig to ensure that the changes persist
-	err := clientcmd.ModifyConfig(configAccess, *opts.Config, true)
-	if err != nil {
-		logHandler.Handle(logger.ErrWriteKubeconfig, err)
+	if !saveConfig(opts) {
 		return
 	}
 
@@ -169,9 +180,70 @@ func renameContext(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigA
 	}, nil)
 }
 
+func renameContextEntries(opts *utils.KubeConfigOptions, context *api.Context) error {
+	// Check both entries before changing anything, so we never end up with a half renamed context
+	renameCluster := shouldRenameEntry(context.Cluster, opts.GetClusterReferences(context.Cluster))
+	if _, exists := opts.Config.Clusters[context.Cluster]; !exists {
+		renameCluster = false
+	} else if _, taken := opts.Config.Clusters[contextTo]; renameCluster && taken {
+		if renameEntries {
+			return fmt.Errorf("cluster %q already exists", contextTo)
+		}
+		renameCluster = false
+	}
+
+	renameAuthInfo := shouldRenameEntry(context.AuthInfo, opts.GetAuthInfoReferences(context.AuthInfo))
+	if _, exists := opts.Config.AuthInfos[context.AuthInfo]; !exists {
+		renameAuthInfo = false
+	} else if _, taken := opts.Config.AuthInfos[contextTo]; renameAuthInfo && taken {
+		if renameEntries {
+			return fmt.Errorf("user %q already exists", contextTo)
+		}
+		renameAuthInfo = false
+	}
+
+	if renameCluster {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("Renaming cluster %s to %s..", color.FgCyan.Render(context.Cluster), color.FgCyan.Render(contextTo)),
+		}, nil)
+		if err := opts.RenameCluster(context.Cluster, contextTo); err != nil {
+			return err
+		}
+	}
+
+	if renameAuthInfo {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("Renaming user %s to %s..", color.FgCyan.Render(context.AuthInfo), color.FgCyan.Render(contextTo)),
+		}, nil)
+		if err := opts.RenameAuthInfo(context.AuthInfo, contextTo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func shouldRenameEntry(name string, references []string) bool {
+	// Nothing to do when the entry already carries the new name
+	if name == contextTo {
+		return false
+	}
+
+	// Always rename when asked to, every context referencing the entry is updated as well
+	if renameEntries {
+		return true
+	}
+
+	// Otherwise only rename entries named after the context which no other context shares, like the ones created by `add`
+	return name == contextFrom && len(references) == 1
+}
+
 // Cobra command initialization
 func init() {
 	rootCmd.AddCommand(renameCmd)
 	renameCmd.Flags().StringVarP(&contextFrom, "from", "f", "", "name of context which you want to rename")
 	renameCmd.Flags().StringVarP(&contextTo, "to", "t", "", "new name of the context")
+	renameCmd.Flags().BoolVar(&renameEntries, "cascade", false, "also rename the cluster and user of the context, updating every context which uses them")
 }
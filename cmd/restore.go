@@ -0,0 +1,192 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"errors"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// Argument definition
+var listBackups bool
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore [backup-id]",
+	Short: "Restore your kubeconfig from a backup",
+	Long:  "Every command which modifies your kubeconfig backs it up first. This command lists those backups and restores the chosen one, the current state is backed up as well so a restore can be undone too.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runRestoreCommand,
+}
+
+// Main logic for restore command
+func runRestoreCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	backups, err := utils.ListBackups()
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to list the backups",
+		}, err)
+		return
+	}
+	if len(backups) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("No backups found in %s.", backupDirectory),
+		}, nil)
+		return
+	}
+
+	if listBackups {
+		for _, backup := range backups {
+			fmt.Printf("- %s %s\n", color.FgCyan.Render(backup.ID), describeBackup(opts, backup))
+		}
+		return
+	}
+
+	// Find the backup to restore, either from the arguments or by asking
+	var backup *utils.Backup
+	if len(args) == 1 {
+		backup, err = utils.GetBackup(args[0])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Could not find backup %s, use --list to see the available backups", color.FgCyan.Render(args[0])),
+			}, err)
+			return
+		}
+	} else {
+		backup = promptForBackup(opts, backups)
+		if backup == nil {
+			return
+		}
+	}
+
+	if !assumeYes {
+		var files []string
+		for _, file := range backup.Files {
+			files = append(files, file.Path)
+		}
+		if !confirmAction(fmt.Sprintf("Overwrite %s with backup %s?", strings.Join(files, ", "), backup.ID)) {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user cancelled restore"))
+			return
+		}
+	}
+
+	// The current state is backed up as well, so the restore itself can be undone
+	current, err := opts.RestoreBackup(backup)
+	if errors.Is(err, utils.ErrDryRun) {
+		showPendingChanges(opts)
+		return
+	} else if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Failed to restore the backup",
+		}, err)
+		return
+	}
+
+	message := fmt.Sprintf("Restored backup %s!", color.FgCyan.Render(backup.ID))
+	if current != nil {
+		message += fmt.Sprintf(" The previous state was backed up as %s.", color.FgCyan.Render(current.ID))
+	}
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: message,
+	}, nil)
+}
+
+func promptForBackup(opts *utils.KubeConfigOptions, backups []utils.Backup) *utils.Backup {
+	var ids []string
+	for _, backup := range backups {
+		ids = append(ids, backup.ID)
+	}
+
+	prompt := &survey.Select{
+		Message: "Choose a backup to restore:",
+		Options: ids,
+		Description: func(value string, index int) string {
+			return describeBackup(opts, backups[index])
+		},
+	}
+
+	var selected int
+	err := survey.AskOne(prompt, &selected)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted restore"))
+			os.Exit(0)
+		} else {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to prompt for backup",
+			}, err)
+		}
+		return nil
+	}
+
+	return &backups[selected]
+}
+
+// describeBackup summarizes when and why a backup was taken, and which contexts restoring it would add or remove
+func describeBackup(opts *utils.KubeConfigOptions, backup utils.Backup) string {
+	description := fmt.Sprintf("taken %s before %s", backup.Created.Local().Format(time.DateTime), backup.Command)
+
+	config, err := backup.Load()
+	if err != nil {
+		return description + ", unreadable"
+	}
+
+	var added, removed []string
+	for _, name := range utils.SortedKeys(config.Contexts) {
+		if _, exists := opts.Config.Contexts[name]; !exists {
+			added = append(added, "+"+name)
+		}
+	}
+	for _, name := range utils.SortedKeys(opts.Config.Contexts) {
+		if _, exists := config.Contexts[name]; !exists {
+			removed = append(removed, "-"+name)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		return description + ", same contexts as now"
+	}
+	return fmt.Sprintf("%s, restoring changes contexts %s", description, strings.Join(append(added, removed...), " "))
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().BoolVarP(&listBackups, "list", "l", false, "list the available backups instead of restoring one")
+	restoreCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "do not ask for confirmation")
+}
@@ -21,9 +21,9 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"path"
 	"errors"
 	"slices"
+	"path/filepath"
 
 	"github.com/gookit/color"
 	"github.com/AlecAivazis/survey/v2"
@@ -37,6 +37,11 @@ import (
 var (
 	debugMode  bool
 	logHandler *logger.Logger
+
+	backupDirectory string
+	backupRetention int
+	journalFile     string
+	dryRun          bool
 )
 
 var rootCmd = &cobra.Command{
@@ -48,6 +53,26 @@ Whether you are working on multiple projects or interacting with various Kuberne
 		// Initialize the logger with the debug mode setting
 		logHandler = logger.New(debugMode)
 		utils.SetLogger(logHandler)
+		utils.SetDryRun(dryRun)
+
+		// The root command switches contexts, name it after what it does
+		command := cmd.Name()
+		if !cmd.HasParent() {
+			command = "switch"
+		}
+
+		// Back up the kubeconfig before every write
+		utils.SetBackupOptions(utils.BackupOptions{
+			Directory: backupDirectory,
+			Retention: backupRetention,
+			Command:   command,
+		})
+
+		// Journal every change, so it can be undone
+		utils.SetJournalOptions(utils.JournalOptions{
+			Path:    journalFile,
+			Command: command,
+		})
 	},
 	Run: ContextSwitcher,
 }
@@ -67,15 +92,23 @@ func SetVersionInfo(version, commit, date string) {
 func ContextSwitcher(cmd *cobra.Command, args []string) {
 	// Initialize configuration struct
 	opts := &utils.KubeConfigOptions{}
-	opts.Init(kubeConfigPath)
+	opts.Load(kubeConfigPath)
 
-	// Retrieve contexts and set up configAccess so we can write the adjusted configuration
+	// Retrieve contexts
 	opts.GetContexts()
-	configAccess := clientcmd.NewDefaultPathOptions()
 
 	// If no context was given, create an interactive prompt
 	if context == "" {
-		promptForContext(opts, &context)
+		if !filterContexts(opts) {
+			return
+		}
+
+		// Switch right away when the selector leaves a single context
+		if contextSelector != "" && len(opts.Contexts) == 1 {
+			context = opts.Contexts[0]
+		} else {
+			promptForContext(opts, &context)
+		}
 		if context == "" {
 			return
 		}
@@ -90,7 +123,7 @@ func ContextSwitcher(cmd *cobra.Command, args []string) {
 	}
 
 	// Switch to the selected context
-	switchContext(opts, configAccess, context)
+	switchContext(opts, context)
 }
 
 func promptForContext(opts *utils.KubeConfigOptions, context *string) {
@@ -116,18 +149,40 @@ func promptForContext(opts *utils.KubeConfigOptions, context *string) {
 	}
 }
 
-func switchContext(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigAccess, context string) {
+func confirmAction(message string) bool {
+	confirmed := false
+
+	// Set up a yes/no prompt, defaulting to "no" so nothing happens by accident
+	prompt := &survey.Confirm{
+		Message: message,
+		Default: false,
+	}
+
+	err := survey.AskOne(prompt, &confirmed)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			logHandler.Handle(logger.ErrUserInterrupt, errors.New("user interrupted confirmation"))
+			os.Exit(0)
+		} else {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: "Failed to prompt for confirmation",
+			}, err)
+		}
+		return false
+	}
+
+	return confirmed
+}
+
+func switchContext(opts *utils.KubeConfigOptions, context string) {
 	// Make sure we're not trying to change to the current context, as that would be pretty pointless
 	if opts.CurrentContext != context {
 		// Change context to the selected name
 		opts.Config.CurrentContext = context
 
 		// Write modified configuration to file
-		if err := clientcmd.ModifyConfig(configAccess, *opts.Config, true); err != nil {
-			logHandler.Handle(logger.ErrorType{
-				Level:   logger.Error,
-				Message: "Failed to modify kubeconfig",
-			}, err)
+		if !saveConfig(opts) {
 			return
 		}
 
@@ -153,17 +208,13 @@ func Execute() {
 
 // Cobra command initialization
 func init() {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		logHandler.Handle(logger.ErrorType{
-			Level:   logger.Error,
-			Message: "Failed to get user home directory",
-		}, err)
-		os.Exit(1)
-	}
-
 	rootCmd.Flags().StringVarP(&context, "context", "c", "", "name of context to which you want to switch")
+	rootCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "only offer contexts whose tags match this selector, e.g. env=prod,team!=payments")
 
-	rootCmd.PersistentFlags().StringVar(&kubeConfigPath, "config", path.Join(home, ".kube/config"), "kubeconfig file location")
+	rootCmd.PersistentFlags().StringVar(&kubeConfigPath, "config", "", "kubeconfig file location (defaults to the $KUBECONFIG file chain or ~/.kube/config)")
+	rootCmd.PersistentFlags().StringVar(&backupDirectory, "backup-dir", filepath.Join(clientcmd.RecommendedConfigDir, "kube-context", "backups"), "directory to store kubeconfig backups in")
+	rootCmd.PersistentFlags().IntVar(&backupRetention, "backup-retention", 10, "number of kubeconfig backups to keep, 0 disables backups")
+	rootCmd.PersistentFlags().StringVar(&journalFile, "journal-file", filepath.Join(clientcmd.RecommendedConfigDir, "kube-context", "journal.json"), "file to journal changes in, so they can be undone")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show the changes to your kubeconfig instead of writing them")
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "verbose", false, "enable debug mode for detailed logs")
 }
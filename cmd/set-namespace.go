@@ -29,8 +29,6 @@ import (
 	"github.com/DB-Vincent/kube-context/pkg/utils"
 	"github.com/DB-Vincent/kube-context/pkg/logger"
 	"github.com/spf13/cobra"
-
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 // Argument definition
@@ -49,9 +47,8 @@ func runSetNamespaceCommand(cmd *cobra.Command, args []string) {
 	opts := &utils.KubeConfigOptions{}
 	opts.Init(kubeConfigPath)
 
-	// Retrieve contexts and set up configAccess so we can write the adjusted configuration
+	// Retrieve contexts
 	opts.GetContexts()
-	configAccess := clientcmd.NewDefaultPathOptions()
 
 	// Retrieve namespace to set as default
 	selectedNamespace := selectNamespace(opts)
@@ -60,7 +57,7 @@ func runSetNamespaceCommand(cmd *cobra.Command, args []string) {
 	}
 
 	// Sets the namespace
-	setNamespace(opts, configAccess, selectedNamespace)
+	setNamespace(opts, selectedNamespace)
 }
 
 func selectNamespace(opts *utils.KubeConfigOptions) string {
@@ -120,10 +117,10 @@ func promptForNamespace(opts *utils.KubeConfigOptions) string {
 	return result
 }
 
-func setNamespace(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigAccess, selectedNamespace string) {
+func setNamespace(opts *utils.KubeConfigOptions, selectedNamespace string) {
 	logHandler.Handle(logger.ErrorType{
 		Level:   logger.Info,
-		Message: fmt.Sprintf("Setting the default namespace to %s..", color.FgCyan.Render(selectedNamespace)),
+		Message: fmt.Sprintf("Setting the default namespace to %s in %s..", color.FgCyan.Render(selectedNamespace), opts.ContextSource(opts.CurrentContext)),
 	}, nil)
 
 	// Set namespace parameter for current context
@@ -131,11 +128,7 @@ func setNamespace(opts *utils.KubeConfigOptions, configAccess clientcmd.ConfigAc
 	context.Namespace = selectedNamespace
 
 	// Write modified configuration to kubeconfig
-	if err := clientcmd.ModifyConfig(configAccess, *opts.Config, true); err != nil {
-		logHandler.Handle(logger.ErrorType{
-			Level:   logger.Error,
-			Message: "Failed to modify kubeconfig",
-		}, err)
+	if !saveConfig(opts) {
 		return
 	}
 
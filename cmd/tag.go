@@ -0,0 +1,277 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gookit/color"
+	"github.com/DB-Vincent/kube-context/pkg/utils"
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+// Argument definition, shared by every command which supports filtering on tags
+var contextSelector string
+
+// tagCmd represents the tag command
+var tagCmd = &cobra.Command{
+	Use:   "tag [context] [key=value...]",
+	Short: "Adds tags to a context, or shows its tags",
+	Long: `Adds tags such as env=prod or team=payments to a context, replacing the value of tags which already exist.
+Without any tags the current tags of the context are shown. Use --selector instead of a context name to tag every matching context at once.
+Tags are stored as an extension of the context, so kubectl keeps accepting your kubeconfig.`,
+	Run: runTagCommand,
+}
+
+// untagCmd represents the untag command
+var untagCmd = &cobra.Command{
+	Use:   "untag [context] key...",
+	Short: "Removes tags from a context",
+	Long: `Removes the given tags from a context. Use --selector instead of a context name to untag every matching context at once.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runUntagCommand,
+}
+
+// Main logic for tag command
+func runTagCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	targets, args := selectTagTargets(opts, args)
+	if len(targets) == 0 {
+		return
+	}
+
+	// Without tags, show the tags the contexts currently have
+	if len(args) == 0 {
+		displayTags(opts, targets)
+		return
+	}
+
+	tags, err := utils.ParseTags(args)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("Invalid tag: %s", err),
+		}, err)
+		return
+	}
+
+	for _, name := range targets {
+		contextInfo := opts.Config.Contexts[name]
+		current, err := utils.GetTags(contextInfo)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to read the tags of context %s", name),
+			}, err)
+			return
+		}
+
+		if current == nil {
+			current = map[string]string{}
+		}
+		for key, value := range tags {
+			current[key] = value
+		}
+
+		if err := utils.SetTags(contextInfo, current); err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to tag context %s", name),
+			}, err)
+			return
+		}
+	}
+
+	// Write modified configuration to kubeconfig file
+	if !saveConfig(opts) {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully tagged %s with %s!", describeTargets(targets), color.FgCyan.Render(utils.FormatTags(tags))),
+	}, nil)
+}
+
+// Main logic for untag command
+func runUntagCommand(cmd *cobra.Command, args []string) {
+	// Initialize configuration struct
+	opts := &utils.KubeConfigOptions{}
+	opts.Load(kubeConfigPath)
+
+	// Retrieve contexts
+	opts.GetContexts()
+
+	targets, keys := selectTagTargets(opts, args)
+	if len(targets) == 0 {
+		return
+	}
+
+	if len(keys) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: "Please provide the keys of the tags to remove",
+		}, fmt.Errorf("no tag keys given"))
+		return
+	}
+
+	var removed []string
+	for _, name := range targets {
+		contextInfo := opts.Config.Contexts[name]
+		tags, err := utils.GetTags(contextInfo)
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to read the tags of context %s", name),
+			}, err)
+			return
+		}
+
+		for _, key := range keys {
+			if _, exists := tags[key]; exists {
+				delete(tags, key)
+				if !slices.Contains(removed, key) {
+					removed = append(removed, key)
+				}
+			}
+		}
+
+		if err := utils.SetTags(contextInfo, tags); err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Error,
+				Message: fmt.Sprintf("Failed to untag context %s", name),
+			}, err)
+			return
+		}
+	}
+
+	if len(removed) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Info,
+			Message: fmt.Sprintf("None of these tags were set on %s, no need to change.", describeTargets(targets)),
+		}, nil)
+		return
+	}
+
+	// Write modified configuration to kubeconfig file
+	if !saveConfig(opts) {
+		return
+	}
+
+	logHandler.Handle(logger.ErrorType{
+		Level:   logger.Info,
+		Message: fmt.Sprintf("Successfully removed %s from %s!", color.FgCyan.Render(strings.Join(removed, ", ")), describeTargets(targets)),
+	}, nil)
+}
+
+// selectTagTargets returns the contexts to work on and the remaining arguments.
+// With --selector every argument is a tag, otherwise the first argument names the context.
+func selectTagTargets(opts *utils.KubeConfigOptions, args []string) ([]string, []string) {
+	if contextSelector != "" {
+		if !filterContexts(opts) {
+			return nil, nil
+		}
+		return opts.Contexts, args
+	}
+
+	// Prompt for the context when none was given
+	if len(args) == 0 {
+		name := ""
+		promptForContext(opts, &name)
+		if name == "" {
+			return nil, nil
+		}
+		return []string{name}, nil
+	}
+
+	if !slices.Contains(opts.Contexts, args[0]) {
+		logHandler.Handle(logger.ErrContextNotFound, fmt.Errorf("context %s not found", args[0]), opts.Contexts)
+		return nil, nil
+	}
+	return args[:1], args[1:]
+}
+
+// filterContexts narrows the contexts down to the ones matching --selector, returning false when none are left
+func filterContexts(opts *utils.KubeConfigOptions) bool {
+	if contextSelector == "" {
+		return true
+	}
+
+	selected, err := opts.SelectContexts(contextSelector)
+	if err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Fatal,
+			Message: fmt.Sprintf("Invalid value for --selector: %s", err),
+		}, err)
+	}
+	opts.Contexts = selected
+
+	if len(selected) == 0 {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Error,
+			Message: fmt.Sprintf("No contexts match selector %s.", color.FgCyan.Render(contextSelector)),
+		}, fmt.Errorf("no contexts match selector"))
+		return false
+	}
+	return true
+}
+
+func displayTags(opts *utils.KubeConfigOptions, targets []string) {
+	for _, name := range targets {
+		tags, err := utils.GetTags(opts.Config.Contexts[name])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Warning,
+				Message: fmt.Sprintf("Failed to read the tags of context %s", name),
+			}, err)
+			continue
+		}
+
+		if len(tags) == 0 {
+			fmt.Printf("- %s has no tags\n", color.FgCyan.Render(name))
+			continue
+		}
+		fmt.Printf("- %s: %s\n", color.FgCyan.Render(name), utils.FormatTags(tags))
+	}
+}
+
+func describeTargets(targets []string) string {
+	if len(targets) == 1 {
+		return "context " + color.FgCyan.Render(targets[0])
+	}
+	return fmt.Sprintf("%s contexts", color.FgCyan.Render(len(targets)))
+}
+
+// Cobra command initialization
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(untagCmd)
+
+	tagCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "tag every context matching this selector, e.g. env=prod,team!=payments")
+	untagCmd.Flags().StringVarP(&contextSelector, "selector", "l", "", "untag every context matching this selector, e.g. env=prod,team!=payments")
+}
@@ -0,0 +1,75 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Tokens are cached per issuer, client and set of scopes
+type tokenCache struct {
+	path string
+}
+
+func newTokenCache(config Config) *tokenCache {
+	key := sha256.Sum256([]byte(strings.Join([]string{config.IssuerURL, config.ClientID, strings.Join(config.Scopes, " ")}, "\n")))
+	return &tokenCache{path: filepath.Join(config.CacheDir, hex.EncodeToString(key[:])+".json")}
+}
+
+// Load returns the cached token, or nil when nothing was cached yet
+func (c *tokenCache) Load() (*Token, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var token Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		// A corrupt cache is no reason to fail, we simply log in again
+		return nil, nil
+	}
+
+	token.Expiry, err = idTokenExpiry(token.IDToken)
+	if err != nil {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// Save stores the token, only readable by the current user as the refresh token grants access to the cluster
+func (c *tokenCache) Save(token *Token) error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
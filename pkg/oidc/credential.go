@@ -0,0 +1,55 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package oidc
+
+import (
+	"encoding/json"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthentication "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+)
+
+// Used when kubectl doesn't tell us which version it wants
+const defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+// ExecCredential renders the token as an ExecCredential, in the API version requested by kubectl through KUBERNETES_EXEC_INFO.
+func ExecCredential(token *Token) ([]byte, error) {
+	apiVersion := defaultExecAPIVersion
+
+	// The v1 and v1beta1 ExecCredential are identical apart from their version, so we only need to figure out which one to report
+	var execInfo metav1.TypeMeta
+	if err := json.Unmarshal([]byte(os.Getenv("KUBERNETES_EXEC_INFO")), &execInfo); err == nil && execInfo.APIVersion != "" {
+		apiVersion = execInfo.APIVersion
+	}
+
+	expiry := metav1.NewTime(token.Expiry)
+	credential := clientauthentication.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ExecCredential",
+			APIVersion: apiVersion,
+		},
+		Status: &clientauthentication.ExecCredentialStatus{
+			Token:               token.IDToken,
+			ExpirationTimestamp: &expiry,
+		},
+	}
+
+	return json.Marshal(credential)
+}
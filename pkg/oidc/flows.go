@@ -0,0 +1,171 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/oauth2"
+)
+
+// loginWithAuthCode runs the authorization code flow with PKCE, receiving the code on a local callback server
+func loginWithAuthCode(ctx context.Context, config Config, oauthConfig *oauth2.Config) (*Token, error) {
+	listenAddress := config.ListenAddress
+	if listenAddress == "" {
+		listenAddress = "127.0.0.1:0"
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the local callback server: %w", err)
+	}
+	defer listener.Close()
+
+	// Work on a copy, the redirect URL depends on the port we got
+	callbackConfig := *oauthConfig
+	callbackConfig.RedirectURL = fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path != "/callback" {
+				http.NotFound(writer, request)
+				return
+			}
+
+			query := request.URL.Query()
+			var result callbackResult
+			switch {
+			case query.Get("error") != "":
+				result.err = fmt.Errorf("the identity provider returned an error: %s %s", query.Get("error"), query.Get("error_description"))
+			case query.Get("state") != state:
+				result.err = errors.New("the state of the callback does not match, please try again")
+			case query.Get("code") == "":
+				result.err = errors.New("the callback does not contain an authorization code")
+			default:
+				result.code = query.Get("code")
+			}
+
+			if result.err != nil {
+				http.Error(writer, result.err.Error(), http.StatusBadRequest)
+			} else {
+				fmt.Fprintln(writer, "Logged in! You can close this window and return to your terminal.")
+			}
+
+			// Only the first callback counts
+			select {
+			case results <- result:
+			default:
+			}
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := callbackConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+	fmt.Fprintf(config.Output, "Open the following URL in your browser to log in:\n\n    %s\n\n", authURL)
+	if config.OpenBrowser {
+		if err := openBrowser(authURL); err != nil {
+			fmt.Fprintf(config.Output, "Could not open your browser: %v\n", err)
+		}
+	}
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	oauthToken, err := callbackConfig.Exchange(ctx, result.code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("could not exchange the authorization code: %w", err)
+	}
+
+	return newToken(oauthToken)
+}
+
+// loginWithDeviceCode runs the device authorization flow, for machines without a browser
+func loginWithDeviceCode(ctx context.Context, config Config, oauthConfig *oauth2.Config) (*Token, error) {
+	deviceAuth, err := oauthConfig.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the device flow: %w", err)
+	}
+
+	verificationURL := deviceAuth.VerificationURI
+	if deviceAuth.VerificationURIComplete != "" {
+		verificationURL = deviceAuth.VerificationURIComplete
+	}
+	fmt.Fprintf(config.Output, "Open %s in a browser and enter the code %s to log in.\n", verificationURL, deviceAuth.UserCode)
+	if config.OpenBrowser {
+		if err := openBrowser(verificationURL); err != nil {
+			fmt.Fprintf(config.Output, "Could not open your browser: %v\n", err)
+		}
+	}
+
+	oauthToken, err := oauthConfig.DeviceAccessToken(ctx, deviceAuth)
+	if err != nil {
+		return nil, fmt.Errorf("could not complete the device flow: %w", err)
+	}
+
+	return newToken(oauthToken)
+}
+
+// randomString returns a random value usable as OAuth2 state
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// openBrowser opens the given URL in the default browser of the user
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
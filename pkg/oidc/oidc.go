@@ -0,0 +1,239 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Supported OIDC login flows
+const (
+	FlowAuthCode = "authcode"
+	FlowDevice   = "device"
+)
+
+var Flows = []string{FlowAuthCode, FlowDevice}
+
+// Config holds everything needed to log in to an OIDC identity provider
+type Config struct {
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	Scopes        []string
+	Flow          string
+	ListenAddress string // Address of the local callback server used by the authorization code flow
+	OpenBrowser   bool
+	CacheDir      string
+	Timeout       time.Duration // How long to wait for the user to log in
+	Output        io.Writer // Where instructions for the user are written to
+}
+
+// Token is the result of a successful login
+type Token struct {
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"-"`
+}
+
+// Endpoints of the identity provider, as advertised by its discovery document
+type providerMetadata struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+// Tokens expiring within this margin are refreshed, so they don't expire while kubectl is using them
+const expiryMargin = 30 * time.Second
+
+// Login returns a valid ID token, using the cached token or refresh token when possible and running the login flow otherwise.
+func Login(ctx context.Context, config Config) (*Token, error) {
+	cache := newTokenCache(config)
+
+	// Reuse the cached ID token while it is still valid
+	cached, err := cache.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && time.Until(cached.Expiry) > expiryMargin {
+		return cached, nil
+	}
+
+	metadata, err := discover(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	oauthConfig := &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Scopes:       config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:       metadata.AuthorizationEndpoint,
+			TokenURL:      metadata.TokenEndpoint,
+			DeviceAuthURL: metadata.DeviceAuthorizationEndpoint,
+		},
+	}
+
+	// Try the refresh token before bothering the user, falling back to a full login when it was revoked or expired
+	if cached != nil && cached.RefreshToken != "" {
+		token, err := refresh(ctx, oauthConfig, cached.RefreshToken)
+		if err == nil {
+			return token, cache.Save(token)
+		}
+		fmt.Fprintf(config.Output, "Could not refresh the OIDC token, logging in again: %v\n", err)
+	}
+
+	// Give up after a while instead of leaving kubectl hanging forever
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	var token *Token
+	switch config.Flow {
+	case FlowAuthCode:
+		token, err = loginWithAuthCode(ctx, config, oauthConfig)
+	case FlowDevice:
+		if metadata.DeviceAuthorizationEndpoint == "" {
+			return nil, fmt.Errorf("identity provider %s does not support the device flow", config.IssuerURL)
+		}
+		token, err = loginWithDeviceCode(ctx, config, oauthConfig)
+	default:
+		return nil, fmt.Errorf("unknown OIDC flow %q", config.Flow)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, cache.Save(token)
+}
+
+// discover retrieves the endpoints of the identity provider from its discovery document
+func discover(ctx context.Context, issuerURL string) (*providerMetadata, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve the OIDC discovery document: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not retrieve the OIDC discovery document: %s returned %s", discoveryURL, response.Status)
+	}
+
+	var metadata providerMetadata
+	if err := json.NewDecoder(response.Body).Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("could not parse the OIDC discovery document: %w", err)
+	}
+	// The API server checks the issuer of the ID token against its configuration, a mismatch would only fail there
+	if metadata.Issuer != issuerURL {
+		return nil, fmt.Errorf("the OIDC discovery document is for issuer %q, which does not match the configured issuer URL %q", metadata.Issuer, issuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+		return nil, errors.New("the OIDC discovery document does not contain an authorization and token endpoint")
+	}
+
+	return &metadata, nil
+}
+
+func refresh(ctx context.Context, oauthConfig *oauth2.Config, refreshToken string) (*Token, error) {
+	// An expired token forces the token source to use the refresh token
+	tokenSource := oauthConfig.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+		Expiry:       time.Unix(1, 0),
+	})
+
+	oauthToken, err := tokenSource.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newToken(oauthToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identity providers don't always hand out a new refresh token, keep using the old one in that case
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// newToken extracts the ID token from an OAuth2 token response
+func newToken(oauthToken *oauth2.Token) (*Token, error) {
+	idToken, ok := oauthToken.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return nil, errors.New("the token response does not contain an ID token, make sure the openid scope is requested")
+	}
+
+	expiry, err := idTokenExpiry(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		IDToken:      idToken,
+		RefreshToken: oauthToken.RefreshToken,
+		Expiry:       expiry,
+	}, nil
+}
+
+// idTokenExpiry reads the expiry of an ID token.
+// The signature isn't verified, that is up to the Kubernetes API server which actually trusts the token.
+func idTokenExpiry(idToken string) (time.Time, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return time.Time{}, errors.New("the ID token is not a valid JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not decode the ID token: %w", err)
+	}
+
+	var claims struct {
+		Expiry int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("could not parse the ID token: %w", err)
+	}
+	if claims.Expiry == 0 {
+		return time.Time{}, errors.New("the ID token does not have an expiry")
+	}
+
+	return time.Unix(claims.Expiry, 0), nil
+}
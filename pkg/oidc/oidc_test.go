@@ -0,0 +1,498 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID    = "kube-context"
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// testIssuer is a stand-in identity provider serving discovery, JWKS, authorization and token endpoints
+type testIssuer struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	issuer        string        // Issuer advertised in the discovery document, defaults to the server URL
+	tokenLifetime time.Duration // Lifetime of the ID tokens handed out
+
+	mu            sync.Mutex
+	challenges    map[string]string // Authorization code to PKCE challenge
+	refreshTokens map[string]bool
+	devicePolls   map[string][]string // Device code to the errors returned before the user approves
+	pollTimes     []time.Time
+	grants        []string
+	issued        int
+}
+
+func newTestIssuer(t *testing.T, tokenLifetime time.Duration) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("could not generate the signing key: %v", err)
+	}
+
+	issuer := &testIssuer{
+		key:           key,
+		tokenLifetime: tokenLifetime,
+		challenges:    map[string]string{},
+		refreshTokens: map[string]bool{},
+		devicePolls:   map[string][]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.handleDiscovery)
+	mux.HandleFunc("/keys", issuer.handleKeys)
+	mux.HandleFunc("/authorize", issuer.handleAuthorize)
+	mux.HandleFunc("/device", issuer.handleDevice)
+	mux.HandleFunc("/token", issuer.handleToken)
+
+	issuer.server = httptest.NewServer(mux)
+	issuer.issuer = issuer.server.URL
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *testIssuer) handleDiscovery(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, map[string]string{
+		"issuer":                        i.issuer,
+		"authorization_endpoint":        i.server.URL + "/authorize",
+		"device_authorization_endpoint": i.server.URL + "/device",
+		"token_endpoint":                i.server.URL + "/token",
+		"jwks_uri":                      i.server.URL + "/keys",
+	})
+}
+
+func (i *testIssuer) handleKeys(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+// handleAuthorize logs the user in right away and redirects back with an authorization code
+func (i *testIssuer) handleAuthorize(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if query.Get("client_id") != testClientID || query.Get("response_type") != "code" {
+		http.Error(writer, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(writer, "PKCE is required", http.StatusBadRequest)
+		return
+	}
+
+	code := i.randomValue()
+	i.mu.Lock()
+	i.challenges[code] = query.Get("code_challenge")
+	i.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(writer, "invalid redirect URI", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(writer, request, redirect.String(), http.StatusFound)
+}
+
+// handleDevice starts a device authorization, the user "approves" it once the polls scripted for it are used up
+func (i *testIssuer) handleDevice(writer http.ResponseWriter, request *http.Request) {
+	if err := request.ParseForm(); err != nil || request.PostForm.Get("client_id") != testClientID {
+		http.Error(writer, "invalid device authorization request", http.StatusBadRequest)
+		return
+	}
+
+	deviceCode := i.randomValue()
+	i.mu.Lock()
+	i.devicePolls[deviceCode] = []string{"authorization_pending", "slow_down"}
+	i.mu.Unlock()
+
+	writeJSON(writer, map[string]any{
+		"device_code":      deviceCode,
+		"user_code":        "ABCD-EFGH",
+		"verification_uri": i.server.URL + "/activate",
+		"expires_in":       60,
+		"interval":         1,
+	})
+}
+
+func (i *testIssuer) handleToken(writer http.ResponseWriter, request *http.Request) {
+	if err := request.ParseForm(); err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientID, _, ok := request.BasicAuth()
+	if !ok {
+		clientID = request.PostForm.Get("client_id")
+	}
+	if clientID != testClientID {
+		tokenError(writer, "invalid_client")
+		return
+	}
+
+	grant := request.PostForm.Get("grant_type")
+
+	// The device flow is for public clients, which identify themselves in the body. The oauth2 package
+	// retries a rejected request with the credentials in the body, so answering only that keeps one
+	// scripted response per poll.
+	if _, _, basicAuth := request.BasicAuth(); basicAuth && grant == deviceCodeGrant {
+		tokenError(writer, "invalid_client")
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.grants = append(i.grants, grant)
+
+	switch grant {
+	case "authorization_code":
+		challenge, exists := i.challenges[request.PostForm.Get("code")]
+		delete(i.challenges, request.PostForm.Get("code"))
+		verifier := sha256.Sum256([]byte(request.PostForm.Get("code_verifier")))
+		if !exists || base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge {
+			tokenError(writer, "invalid_grant")
+			return
+		}
+	case deviceCodeGrant:
+		polls, exists := i.devicePolls[request.PostForm.Get("device_code")]
+		if !exists {
+			tokenError(writer, "invalid_grant")
+			return
+		}
+		i.pollTimes = append(i.pollTimes, time.Now())
+		if len(polls) > 0 {
+			i.devicePolls[request.PostForm.Get("device_code")] = polls[1:]
+			tokenError(writer, polls[0])
+			return
+		}
+		delete(i.devicePolls, request.PostForm.Get("device_code"))
+	case "refresh_token":
+		refreshToken := request.PostForm.Get("refresh_token")
+		if !i.refreshTokens[refreshToken] {
+			tokenError(writer, "invalid_grant")
+			return
+		}
+		// Refresh tokens are rotated, the old one can't be used again
+		delete(i.refreshTokens, refreshToken)
+	default:
+		tokenError(writer, "unsupported_grant_type")
+		return
+	}
+
+	refreshToken := i.randomValue()
+	i.refreshTokens[refreshToken] = true
+	i.issued++
+
+	writeJSON(writer, map[string]any{
+		"access_token":  i.randomValue(),
+		"token_type":    "Bearer",
+		"expires_in":    int(i.tokenLifetime.Seconds()),
+		"refresh_token": refreshToken,
+		"id_token":      i.signIDToken(fmt.Sprintf("user-%d", i.issued)),
+	})
+}
+
+// signIDToken returns an RS256 signed ID token, the subject makes every token unique
+func (i *testIssuer) signIDToken(subject string) string {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss": i.issuer,
+		"sub": subject,
+		"aud": testClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(i.tokenLifetime).Unix(),
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (i *testIssuer) randomValue() string {
+	value, err := randomString()
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// revokeRefreshTokens makes every refresh token handed out so far invalid
+func (i *testIssuer) revokeRefreshTokens() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.refreshTokens = map[string]bool{}
+}
+
+// takeGrants returns the grant types used since the last call
+func (i *testIssuer) takeGrants() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	grants := i.grants
+	i.grants = nil
+	return grants
+}
+
+func (i *testIssuer) config(t *testing.T, output *testBrowser) Config {
+	return Config{
+		IssuerURL: i.issuer,
+		ClientID:  testClientID,
+		Scopes:    []string{"openid"},
+		Flow:      FlowAuthCode,
+		CacheDir:  t.TempDir(),
+		Timeout:   10 * time.Second,
+		Output:    output,
+	}
+}
+
+// testBrowser follows the login URL printed by the authorization code flow, like a user would
+type testBrowser struct {
+	mu     sync.Mutex
+	visits int
+}
+
+func (b *testBrowser) Write(data []byte) (int, error) {
+	for _, field := range strings.Fields(string(data)) {
+		if !strings.HasPrefix(field, "http") {
+			continue
+		}
+
+		b.mu.Lock()
+		b.visits++
+		b.mu.Unlock()
+
+		// The callback server only answers once the login finished, so visit it in the background
+		go func(loginURL string) {
+			if response, err := http.Get(loginURL); err == nil {
+				response.Body.Close()
+			}
+		}(field)
+	}
+	return len(data), nil
+}
+
+func (b *testBrowser) Visits() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.visits
+}
+
+func writeJSON(writer http.ResponseWriter, value any) {
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(value)
+}
+
+func tokenError(writer http.ResponseWriter, code string) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(writer).Encode(map[string]string{"error": code})
+}
+
+func subject(t *testing.T, idToken string) string {
+	t.Helper()
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(idToken, ".")[1])
+	if err != nil {
+		t.Fatalf("could not decode the ID token: %v", err)
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("could not parse the ID token: %v", err)
+	}
+	return claims.Subject
+}
+
+func equalGrants(got []string, want ...string) bool {
+	return strings.Join(got, ",") == strings.Join(want, ",")
+}
+
+func TestLoginWithAuthCodeThenRefresh(t *testing.T) {
+	// Tokens expiring within the expiry margin are refreshed on the next login
+	issuer := newTestIssuer(t, expiryMargin/2)
+	browser := &testBrowser{}
+	config := issuer.config(t, browser)
+
+	token, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if grants := issuer.takeGrants(); !equalGrants(grants, "authorization_code") {
+		t.Fatalf("expected an authorization code grant, got %v", grants)
+	}
+	if browser.Visits() != 1 {
+		t.Fatalf("expected the login URL to be visited once, got %d", browser.Visits())
+	}
+	if token.RefreshToken == "" || subject(t, token.IDToken) != "user-1" {
+		t.Fatalf("unexpected token after login: %+v", token)
+	}
+
+	refreshed, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if grants := issuer.takeGrants(); !equalGrants(grants, "refresh_token") {
+		t.Fatalf("expected a refresh token grant, got %v", grants)
+	}
+	if browser.Visits() != 1 {
+		t.Fatalf("refreshing should not need the browser, it was visited %d times", browser.Visits())
+	}
+	if subject(t, refreshed.IDToken) != "user-2" || refreshed.RefreshToken == token.RefreshToken {
+		t.Fatalf("expected a new ID token and rotated refresh token, got %+v", refreshed)
+	}
+}
+
+func TestLoginFallsBackWhenRefreshFails(t *testing.T) {
+	issuer := newTestIssuer(t, expiryMargin/2)
+	browser := &testBrowser{}
+	config := issuer.config(t, browser)
+
+	if _, err := Login(context.Background(), config); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	issuer.takeGrants()
+	issuer.revokeRefreshTokens()
+
+	token, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("login after a failed refresh failed: %v", err)
+	}
+	// The oauth2 package retries a rejected refresh with the client credentials in the body, so count attempts loosely
+	grants := issuer.takeGrants()
+	if len(grants) < 2 || grants[0] != "refresh_token" || grants[len(grants)-1] != "authorization_code" {
+		t.Fatalf("expected a refresh attempt followed by a new login, got %v", grants)
+	}
+	if browser.Visits() != 2 || subject(t, token.IDToken) != "user-2" {
+		t.Fatalf("expected a second browser login, got %d visits and token %+v", browser.Visits(), token)
+	}
+}
+
+func TestLoginUsesCachedToken(t *testing.T) {
+	issuer := newTestIssuer(t, time.Hour)
+	browser := &testBrowser{}
+	config := issuer.config(t, browser)
+
+	token, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	issuer.takeGrants()
+
+	cached, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("login from cache failed: %v", err)
+	}
+	if grants := issuer.takeGrants(); len(grants) != 0 {
+		t.Fatalf("a valid cached token should not hit the token endpoint, got %v", grants)
+	}
+	if cached.IDToken != token.IDToken {
+		t.Fatal("expected the cached ID token to be returned")
+	}
+}
+
+func TestDiscoverRejectsMismatchingIssuer(t *testing.T) {
+	issuer := newTestIssuer(t, time.Hour)
+
+	if _, err := discover(context.Background(), issuer.server.URL); err != nil {
+		t.Fatalf("discovery of a matching issuer failed: %v", err)
+	}
+
+	issuer.issuer = "https://other.example.com"
+	_, err := discover(context.Background(), issuer.server.URL)
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected a mismatching issuer to be rejected, got %v", err)
+	}
+
+	// A trailing slash makes it a different issuer as far as the API server is concerned
+	issuer.issuer = issuer.server.URL + "/"
+	if _, err := discover(context.Background(), issuer.server.URL); err == nil {
+		t.Fatal("expected an issuer with a different trailing slash to be rejected")
+	}
+}
+
+func TestLoginWithDeviceCode(t *testing.T) {
+	issuer := newTestIssuer(t, time.Hour)
+	var output bytes.Buffer
+	config := issuer.config(t, nil)
+	config.Flow = FlowDevice
+	config.Output = &output
+	// Polling takes a while, the slow_down response adds 5 seconds to the interval
+	config.Timeout = 30 * time.Second
+
+	token, err := Login(context.Background(), config)
+	if err != nil {
+		t.Fatalf("device login failed: %v", err)
+	}
+	if !strings.Contains(output.String(), issuer.server.URL+"/activate") || !strings.Contains(output.String(), "ABCD-EFGH") {
+		t.Fatalf("expected the verification URL and user code to be shown, got %q", output.String())
+	}
+	if grants := issuer.takeGrants(); !equalGrants(grants, deviceCodeGrant, deviceCodeGrant, deviceCodeGrant) {
+		t.Fatalf("expected polling through authorization_pending and slow_down, got %v", grants)
+	}
+	if subject(t, token.IDToken) != "user-1" || token.RefreshToken == "" {
+		t.Fatalf("unexpected token after device login: %+v", token)
+	}
+
+	// After slow_down the client has to wait at least 5 seconds longer between polls
+	issuer.mu.Lock()
+	pollTimes := issuer.pollTimes
+	issuer.mu.Unlock()
+	if wait := pollTimes[2].Sub(pollTimes[1]); wait < 5*time.Second {
+		t.Fatalf("expected the polling interval to increase after slow_down, polled again after %s", wait)
+	}
+}
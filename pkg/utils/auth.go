@@ -0,0 +1,77 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Ways a user can authenticate, as detected from its configuration
+const (
+	AuthMethodCertificate  = "certificate"
+	AuthMethodToken        = "token"
+	AuthMethodBasic        = "basic"
+	AuthMethodExec         = "exec"
+	AuthMethodOIDC         = "oidc"
+	AuthMethodAuthProvider = "auth-provider"
+	AuthMethodNone         = "none"
+)
+
+// AuthMethod returns how the given user authenticates.
+// OIDC is detected both for the built-in oidc-login plugin and the deprecated oidc auth provider.
+func AuthMethod(authInfo *api.AuthInfo) string {
+	switch {
+	case authInfo == nil:
+		return AuthMethodNone
+	case authInfo.Exec != nil:
+		if filepath.Base(authInfo.Exec.Command) == "kube-context" && slices.Contains(authInfo.Exec.Args, "oidc-login") {
+			return AuthMethodOIDC
+		}
+		return AuthMethodExec
+	case authInfo.AuthProvider != nil:
+		if authInfo.AuthProvider.Name == "oidc" {
+			return AuthMethodOIDC
+		}
+		return AuthMethodAuthProvider
+	case authInfo.Token != "" || authInfo.TokenFile != "":
+		return AuthMethodToken
+	case authInfo.ClientCertificate != "" || len(authInfo.ClientCertificateData) > 0:
+		return AuthMethodCertificate
+	case authInfo.Username != "" || authInfo.Password != "":
+		return AuthMethodBasic
+	default:
+		return AuthMethodNone
+	}
+}
+
+// DescribeAuthMethod returns a short, secret-free description of how the given user authenticates.
+func DescribeAuthMethod(authInfo *api.AuthInfo) string {
+	method := AuthMethod(authInfo)
+	switch method {
+	case AuthMethodExec:
+		return method + " (" + filepath.Base(authInfo.Exec.Command) + ")"
+	case AuthMethodAuthProvider:
+		return method + " (" + authInfo.AuthProvider.Name + ")"
+	default:
+		return method
+	}
+}
@@ -0,0 +1,266 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// BackupOptions configures the backups taken before every write
+type BackupOptions struct {
+	Directory string
+	Retention int    // Number of backups to keep, 0 disables backups
+	Command   string // Command which triggered the write, shown when restoring
+}
+
+var backupOptions BackupOptions
+
+// SetBackupOptions configures the backups taken by WriteConfig
+func SetBackupOptions(options BackupOptions) {
+	backupOptions = options
+}
+
+// Name of the file describing a backup
+const backupManifest = "backup.json"
+
+// Backup is a copy of the kubeconfig file(s) taken before a write
+type Backup struct {
+	ID        string       `json:"-"`
+	Directory string       `json:"-"`
+	Created   time.Time    `json:"created"`
+	Command   string       `json:"command"`
+	Files     []BackupFile `json:"files"`
+}
+
+// BackupFile maps a kubeconfig file to its copy in the backup
+type BackupFile struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// CreateBackup copies every kubeconfig file the configuration was loaded from into a new, timestamped backup.
+// Older backups are removed according to the retention. It returns nil if backups are disabled.
+func (opts *KubeConfigOptions) CreateBackup() (*Backup, error) {
+	if backupOptions.Retention <= 0 || backupOptions.Directory == "" {
+		return nil, nil
+	}
+
+	files := opts.GetSourceFiles()
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(backupOptions.Directory, 0700); err != nil {
+		return nil, err
+	}
+
+	// Backups taken within the same millisecond get a counter, so they never overwrite each other
+	backup := &Backup{Created: time.Now(), Command: backupOptions.Command}
+	id := backup.Created.Format("20060102-150405.000")
+	backup.ID = id
+	for i := 2; ; i++ {
+		backup.Directory = filepath.Join(backupOptions.Directory, backup.ID)
+		err := os.Mkdir(backup.Directory, 0700)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		backup.ID = fmt.Sprintf("%s-%d", id, i)
+	}
+
+	for i, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		name := fmt.Sprintf("%d-%s", i, filepath.Base(file))
+		if err := os.WriteFile(filepath.Join(backup.Directory, name), data, 0600); err != nil {
+			return nil, err
+		}
+		backup.Files = append(backup.Files, BackupFile{Path: file, Name: name})
+	}
+
+	manifest, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(backup.Directory, backupManifest), manifest, 0600); err != nil {
+		return nil, err
+	}
+
+	return backup, pruneBackups()
+}
+
+// ListBackups returns the available backups, newest first.
+func ListBackups() ([]Backup, error) {
+	entries, err := os.ReadDir(backupOptions.Directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// Skip anything which isn't a (complete) backup
+		backup, err := readBackup(entry.Name())
+		if err != nil {
+			continue
+		}
+		backups = append(backups, *backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+
+	return backups, nil
+}
+
+// GetBackup returns the backup with the given ID.
+func GetBackup(id string) (*Backup, error) {
+	// IDs are directory names, don't let them point anywhere else
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid backup ID %q", id)
+	}
+
+	backup, err := readBackup(id)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not find backup %q", id)
+	}
+	return backup, err
+}
+
+func readBackup(id string) (*Backup, error) {
+	directory := filepath.Join(backupOptions.Directory, id)
+	data, err := os.ReadFile(filepath.Join(directory, backupManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("backup %q is corrupt: %w", id, err)
+	}
+	backup.ID = id
+	backup.Directory = directory
+
+	return &backup, nil
+}
+
+// Load merges the files in the backup into a single configuration, the same way the kubeconfig files are merged.
+func (backup *Backup) Load() (*api.Config, error) {
+	var precedence []string
+	for _, file := range backup.Files {
+		precedence = append(precedence, filepath.Join(backup.Directory, file.Name))
+	}
+
+	loadingRules := &clientcmd.ClientConfigLoadingRules{Precedence: precedence, DoNotResolvePaths: true}
+	return loadingRules.Load()
+}
+
+// RestoreBackup copies the files in the backup back to where they were taken from.
+// The current files are backed up first, so the restore itself can be undone. It returns that backup, if any.
+// In dry-run mode nothing is written, the configuration is replaced by how it would look after the restore instead.
+func (opts *KubeConfigOptions) RestoreBackup(backup *Backup) (*Backup, error) {
+	if dryRun {
+		config, err := opts.previewRestore(backup)
+		if err != nil {
+			return nil, err
+		}
+		opts.Config = config
+		return nil, ErrDryRun
+	}
+
+	// Read everything up front, taking the new backup may prune the one we're restoring
+	contents := make([][]byte, len(backup.Files))
+	for i, file := range backup.Files {
+		data, err := os.ReadFile(filepath.Join(backup.Directory, file.Name))
+		if err != nil {
+			return nil, err
+		}
+		contents[i] = data
+	}
+
+	current, err := opts.CreateBackup()
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up the current kubeconfig: %w", err)
+	}
+
+	for i, file := range backup.Files {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0700); err != nil {
+			return current, err
+		}
+		if err := os.WriteFile(file.Path, contents[i], 0600); err != nil {
+			return current, err
+		}
+	}
+	return current, nil
+}
+
+// previewRestore merges the kubeconfig files the way they would be after restoring the backup
+func (opts *KubeConfigOptions) previewRestore(backup *Backup) (*api.Config, error) {
+	restored := map[string]string{}
+	for _, file := range backup.Files {
+		restored[file.Path] = filepath.Join(backup.Directory, file.Name)
+	}
+
+	// Files which aren't part of the backup stay as they are
+	var precedence []string
+	for _, file := range opts.ConfigAccess.GetLoadingPrecedence() {
+		if backupFile, exists := restored[file]; exists {
+			file = backupFile
+		}
+		precedence = append(precedence, file)
+	}
+
+	loadingRules := &clientcmd.ClientConfigLoadingRules{Precedence: precedence, DoNotResolvePaths: true}
+	return loadingRules.Load()
+}
+
+// pruneBackups removes the oldest backups until only the configured number remains
+func pruneBackups() error {
+	backups, err := ListBackups()
+	if err != nil {
+		return err
+	}
+
+	for i := backupOptions.Retention; i < len(backups); i++ {
+		if err := os.RemoveAll(backups[i].Directory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Certificates expiring within this period deserve a warning
+const CertificateExpiryWarning = 30 * 24 * time.Hour
+
+// ParseCertificates parses every certificate in PEM data, e.g. a certificate authority bundle.
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	if err := ValidatePEM(data, "CERTIFICATE"); err != nil {
+		return nil, err
+	}
+
+	var certificates []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
+
+// CheckKeyPair verifies that the private key belongs to the certificate.
+func CheckKeyPair(certificate []byte, key []byte) error {
+	if _, err := tls.X509KeyPair(certificate, key); err != nil {
+		return fmt.Errorf("the client key does not match the client certificate: %w", err)
+	}
+	return nil
+}
+
+// IsSignedBy returns true if the certificate was signed by one of the certificate authorities.
+func IsSignedBy(certificate *x509.Certificate, authorities []*x509.Certificate) bool {
+	for _, authority := range authorities {
+		if certificate.CheckSignatureFrom(authority) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// DaysRemaining returns the number of whole days until the certificate expires, negative once it has expired.
+func DaysRemaining(certificate *x509.Certificate, now time.Time) int {
+	return int(math.Floor(certificate.NotAfter.Sub(now).Hours() / 24))
+}
+
+// DescribeCertificate returns a one-line summary of the subject, issuer and expiry of a certificate.
+func DescribeCertificate(certificate *x509.Certificate) string {
+	return fmt.Sprintf("subject %q, issued by %q, expires %s", certificate.Subject.String(), certificate.Issuer.String(), certificate.NotAfter.Local().Format(time.DateTime))
+}
+
+// ExpiryProblem describes why the certificate needs attention, or returns an empty string if it doesn't.
+func ExpiryProblem(certificate *x509.Certificate, now time.Time) string {
+	switch {
+	case now.After(certificate.NotAfter):
+		return fmt.Sprintf("expired %d days ago", -DaysRemaining(certificate, now))
+	case now.Before(certificate.NotBefore):
+		return fmt.Sprintf("is not valid until %s", certificate.NotBefore.Local().Format(time.DateTime))
+	case certificate.NotAfter.Sub(now) < CertificateExpiryWarning:
+		return fmt.Sprintf("expires in %d days", DaysRemaining(certificate, now))
+	default:
+		return ""
+	}
+}
+
+// ClusterCertificateAuthority returns the certificate authority of a cluster, either embedded or read from its file.
+// It returns nil if the cluster has no certificate authority.
+func ClusterCertificateAuthority(cluster *api.Cluster) ([]byte, error) {
+	if len(cluster.CertificateAuthorityData) > 0 {
+		return cluster.CertificateAuthorityData, nil
+	}
+	if cluster.CertificateAuthority == "" {
+		return nil, nil
+	}
+	return os.ReadFile(ResolveFilePath(cluster.CertificateAuthority, cluster.LocationOfOrigin))
+}
+
+// AuthInfoClientCertificate returns the client certificate of a user, either embedded or read from its file.
+// It returns nil if the user doesn't authenticate with a client certificate.
+func AuthInfoClientCertificate(authInfo *api.AuthInfo) ([]byte, error) {
+	if len(authInfo.ClientCertificateData) > 0 {
+		return authInfo.ClientCertificateData, nil
+	}
+	if authInfo.ClientCertificate == "" {
+		return nil, nil
+	}
+	return os.ReadFile(ResolveFilePath(authInfo.ClientCertificate, authInfo.LocationOfOrigin))
+}
+
+// CertificateReport describes a single certificate found in the kubeconfig
+type CertificateReport struct {
+	Kind          string // "cluster" or "user"
+	Name          string
+	Field         string // "certificate-authority" or "client-certificate"
+	Source        string // File the certificate was read from, or "embedded"
+	Subject       string
+	Issuer        string
+	NotAfter      time.Time
+	DaysRemaining int
+	Err           error // Set when the certificate couldn't be read or parsed
+}
+
+// FindCertificates collects every certificate authority and client certificate, both file-based and embedded.
+// The reports are sorted by expiry, soonest first, with unreadable certificates on top.
+func (opts *KubeConfigOptions) FindCertificates(now time.Time) []CertificateReport {
+	var reports []CertificateReport
+
+	for _, name := range SortedKeys(opts.Config.Clusters) {
+		cluster := opts.Config.Clusters[name]
+		source := ResolveFilePath(cluster.CertificateAuthority, cluster.LocationOfOrigin)
+		if len(cluster.CertificateAuthorityData) > 0 {
+			source = "embedded"
+		}
+
+		data, err := ClusterCertificateAuthority(cluster)
+		reports = append(reports, certificateReports("cluster", name, "certificate-authority", source, data, err, now)...)
+	}
+
+	for _, name := range SortedKeys(opts.Config.AuthInfos) {
+		authInfo := opts.Config.AuthInfos[name]
+		source := ResolveFilePath(authInfo.ClientCertificate, authInfo.LocationOfOrigin)
+		if len(authInfo.ClientCertificateData) > 0 {
+			source = "embedded"
+		}
+
+		data, err := AuthInfoClientCertificate(authInfo)
+		reports = append(reports, certificateReports("user", name, "client-certificate", source, data, err, now)...)
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		if (reports[i].Err != nil) != (reports[j].Err != nil) {
+			return reports[i].Err != nil
+		}
+		return reports[i].NotAfter.Before(reports[j].NotAfter)
+	})
+
+	return reports
+}
+
+// certificateReports turns the certificate data of a single entry into reports, one for every certificate in a bundle
+func certificateReports(kind string, name string, field string, source string, data []byte, err error, now time.Time) []CertificateReport {
+	report := CertificateReport{Kind: kind, Name: name, Field: field, Source: source}
+
+	// Nothing configured, nothing to report
+	if err == nil && data == nil {
+		return nil
+	}
+
+	var certificates []*x509.Certificate
+	if err == nil {
+		certificates, err = ParseCertificates(data)
+	}
+	if err != nil {
+		report.Err = err
+		return []CertificateReport{report}
+	}
+
+	var reports []CertificateReport
+	for _, certificate := range certificates {
+		report.Subject = certificate.Subject.String()
+		report.Issuer = certificate.Issuer.String()
+		report.NotAfter = certificate.NotAfter
+		report.DaysRemaining = DaysRemaining(certificate, now)
+		reports = append(reports, report)
+	}
+	return reports
+}
@@ -0,0 +1,209 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// How an entry differs between two configurations
+const (
+	EntryAdded   = "added"
+	EntryRemoved = "removed"
+	EntryChanged = "changed"
+)
+
+// EntryDifference describes a context, cluster or user which differs between two configurations
+type EntryDifference struct {
+	Kind   string // "context", "cluster" or "user"
+	Name   string
+	Change string // EntryAdded, EntryRemoved or EntryChanged
+	Fields []FieldDifference
+}
+
+// FieldDifference describes a single changed field, secrets are redacted
+type FieldDifference struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// entryField is a single comparable field of an entry, with the value to compare and the value to show
+type entryField struct {
+	name    string
+	value   string
+	display string
+}
+
+// CompareConfigs compares two configurations entry by entry, rather than by text.
+// Contexts come first, then clusters and users, each sorted by name.
+func CompareConfigs(before *api.Config, after *api.Config) []EntryDifference {
+	var differences []EntryDifference
+	differences = append(differences, compareEntries("context", before.Contexts, after.Contexts, contextFields)...)
+	differences = append(differences, compareEntries("cluster", before.Clusters, after.Clusters, clusterFields)...)
+	differences = append(differences, compareEntries("user", before.AuthInfos, after.AuthInfos, authInfoFields)...)
+	return differences
+}
+
+func compareEntries[T any](kind string, before map[string]T, after map[string]T, fields func(T) []entryField) []EntryDifference {
+	names := append(SortedKeys(before), SortedKeys(after)...)
+	slices.Sort(names)
+
+	var differences []EntryDifference
+	for _, name := range slices.Compact(names) {
+		beforeEntry, inBefore := before[name]
+		afterEntry, inAfter := after[name]
+
+		switch {
+		case !inBefore:
+			differences = append(differences, EntryDifference{Kind: kind, Name: name, Change: EntryAdded})
+		case !inAfter:
+			differences = append(differences, EntryDifference{Kind: kind, Name: name, Change: EntryRemoved})
+		default:
+			if changed := compareFields(fields(beforeEntry), fields(afterEntry)); len(changed) > 0 {
+				differences = append(differences, EntryDifference{Kind: kind, Name: name, Change: EntryChanged, Fields: changed})
+			}
+		}
+	}
+	return differences
+}
+
+// compareFields lists the fields whose values differ, both sides always list the same fields in the same order
+func compareFields(before []entryField, after []entryField) []FieldDifference {
+	var differences []FieldDifference
+	for i := range before {
+		if before[i].value != after[i].value {
+			differences = append(differences, FieldDifference{Field: before[i].name, Before: before[i].display, After: after[i].display})
+		}
+	}
+	return differences
+}
+
+func plainField(name string, value string) entryField {
+	return entryField{name: name, value: value, display: value}
+}
+
+// secretField compares the actual secret, but only ever shows whether it's set
+func secretField(name string, value string) entryField {
+	display := ""
+	if value != "" {
+		display = Redacted
+	}
+	return entryField{name: name, value: value, display: display}
+}
+
+// dataField compares embedded data by fingerprint, as the data itself is unreadable
+func dataField(name string, data []byte) entryField {
+	fingerprint := ""
+	if len(data) > 0 {
+		fingerprint = fmt.Sprintf("sha256:%x", sha256.Sum256(data))[:19]
+	}
+	return plainField(name, fingerprint)
+}
+
+// extensionsField compares extensions by name and content, leaving out the one shown as a field of its own.
+// Extensions whose name suggests a secret only show whether they're set.
+func extensionsField(extensions map[string]runtime.Object, skip string) entryField {
+	var values, displays []string
+	for _, name := range SortedKeys(extensions) {
+		if name == skip {
+			continue
+		}
+
+		value := extensionValue(extensions[name])
+		values = append(values, name+"="+value)
+		if isSecretName(name) {
+			value = Redacted
+		}
+		displays = append(displays, name+"="+value)
+	}
+	return entryField{name: "extensions", value: strings.Join(values, "\n"), display: strings.Join(displays, ", ")}
+}
+
+func contextFields(context *api.Context) []entryField {
+	// Unreadable tags still show up as a changed extension
+	tags, _ := GetTags(context)
+
+	return []entryField{
+		plainField("cluster", context.Cluster),
+		plainField("user", context.AuthInfo),
+		plainField("namespace", context.Namespace),
+		plainField("tags", FormatTags(tags)),
+		extensionsField(context.Extensions, TagsExtension),
+	}
+}
+
+func clusterFields(cluster *api.Cluster) []entryField {
+	return []entryField{
+		plainField("server", cluster.Server),
+		plainField("certificate-authority", cluster.CertificateAuthority),
+		dataField("certificate-authority-data", cluster.CertificateAuthorityData),
+		plainField("tls-server-name", cluster.TLSServerName),
+		plainField("insecure-skip-tls-verify", strconv.FormatBool(cluster.InsecureSkipTLSVerify)),
+		plainField("proxy-url", cluster.ProxyURL),
+		plainField("disable-compression", strconv.FormatBool(cluster.DisableCompression)),
+		extensionsField(cluster.Extensions, ""),
+	}
+}
+
+func authInfoFields(authInfo *api.AuthInfo) []entryField {
+	fields := []entryField{
+		plainField("auth type", DescribeAuthMethod(authInfo)),
+		plainField("client-certificate", authInfo.ClientCertificate),
+		dataField("client-certificate-data", authInfo.ClientCertificateData),
+		plainField("client-key", authInfo.ClientKey),
+		secretField("client-key-data", string(authInfo.ClientKeyData)),
+		secretField("token", authInfo.Token),
+		plainField("token-file", authInfo.TokenFile),
+		plainField("username", authInfo.Username),
+		secretField("password", authInfo.Password),
+		plainField("as", authInfo.Impersonate),
+		extensionsField(authInfo.Extensions, ""),
+	}
+
+	// Compare the exec plugin and auth provider as a whole, but show them without secrets
+	redacted := authInfo.DeepCopy()
+	RedactAuthInfo(redacted)
+
+	exec, execDisplay := "", ""
+	if authInfo.Exec != nil {
+		exec = fmt.Sprintf("%s %q %v %s", authInfo.Exec.Command, authInfo.Exec.Args, authInfo.Exec.Env, authInfo.Exec.APIVersion)
+		execDisplay = strings.TrimSpace(redacted.Exec.Command + " " + strings.Join(redacted.Exec.Args, " "))
+		for _, env := range redacted.Exec.Env {
+			execDisplay += fmt.Sprintf(" (%s=%s)", env.Name, env.Value)
+		}
+	}
+	fields = append(fields, entryField{name: "exec", value: exec, display: execDisplay})
+
+	provider, providerDisplay := "", ""
+	if authInfo.AuthProvider != nil {
+		provider = fmt.Sprintf("%s %v", authInfo.AuthProvider.Name, authInfo.AuthProvider.Config)
+		providerDisplay = fmt.Sprintf("%s %v", redacted.AuthProvider.Name, redacted.AuthProvider.Config)
+	}
+	fields = append(fields, entryField{name: "auth-provider", value: provider, display: providerDisplay})
+
+	return fields
+}
@@ -0,0 +1,258 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"crypto/x509"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ContextDescription holds everything there is to know about a context, without secrets
+type ContextDescription struct {
+	Name       string              `json:"name"`
+	Current    bool                `json:"current"`
+	Source     string              `json:"source"`
+	Namespace  string              `json:"namespace,omitempty"`
+	Tags       map[string]string   `json:"tags,omitempty"`
+	Extensions map[string]string   `json:"extensions,omitempty"`
+	Cluster    ClusterDescription  `json:"cluster"`
+	User       AuthInfoDescription `json:"user"`
+}
+
+// ClusterDescription describes the cluster of a context
+type ClusterDescription struct {
+	Name                  string                   `json:"name"`
+	Missing               bool                     `json:"missing,omitempty"`
+	Source                string                   `json:"source,omitempty"`
+	Server                string                   `json:"server,omitempty"`
+	TLSServerName         string                   `json:"tlsServerName,omitempty"`
+	InsecureSkipTLSVerify bool                     `json:"insecureSkipTLSVerify,omitempty"`
+	ProxyURL              string                   `json:"proxyURL,omitempty"`
+	DisableCompression    bool                     `json:"disableCompression,omitempty"`
+	CertificateAuthority  string                   `json:"certificateAuthority,omitempty"` // File, "embedded" or "system"
+	Certificates          []CertificateDescription `json:"certificates,omitempty"`
+	Extensions            map[string]string        `json:"extensions,omitempty"`
+}
+
+// AuthInfoDescription describes the user of a context
+type AuthInfoDescription struct {
+	Name              string                   `json:"name"`
+	Missing           bool                     `json:"missing,omitempty"`
+	Source            string                   `json:"source,omitempty"`
+	AuthMethod        string                   `json:"authMethod,omitempty"`
+	ClientCertificate string                   `json:"clientCertificate,omitempty"` // File or "embedded"
+	ClientKey         string                   `json:"clientKey,omitempty"`         // File or "embedded"
+	Certificates      []CertificateDescription `json:"certificates,omitempty"`
+	Token             string                   `json:"token,omitempty"` // Always redacted
+	TokenFile         string                   `json:"tokenFile,omitempty"`
+	Username          string                   `json:"username,omitempty"`
+	Password          string                   `json:"password,omitempty"` // Always redacted
+	Exec              *ExecDescription         `json:"exec,omitempty"`
+	AuthProvider      string                   `json:"authProvider,omitempty"`
+	Impersonate       string                   `json:"impersonate,omitempty"`
+	Extensions        map[string]string        `json:"extensions,omitempty"`
+}
+
+// ExecDescription describes an exec credential plugin, secrets in its arguments and environment are redacted
+type ExecDescription struct {
+	Command         string   `json:"command"`
+	Args            []string `json:"args,omitempty"`
+	Env             []string `json:"env,omitempty"`
+	APIVersion      string   `json:"apiVersion,omitempty"`
+	InteractiveMode string   `json:"interactiveMode,omitempty"`
+}
+
+// CertificateDescription describes a single certificate and when it expires
+type CertificateDescription struct {
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	NotAfter      time.Time `json:"notAfter,omitempty"`
+	DaysRemaining int       `json:"daysRemaining"`
+	Problem       string    `json:"problem,omitempty"` // Expired or about to expire
+	Error         string    `json:"error,omitempty"`   // Set when the certificate couldn't be read
+}
+
+// DescribeContext collects the details of a context, its cluster and its user without contacting the cluster.
+func (opts *KubeConfigOptions) DescribeContext(name string, now time.Time) (*ContextDescription, error) {
+	context, exists := opts.Config.Contexts[name]
+	if !exists {
+		return nil, fmt.Errorf("context %q not found", name)
+	}
+
+	description := &ContextDescription{
+		Name:       name,
+		Current:    opts.Config.CurrentContext == name,
+		Source:     opts.ContextSource(name),
+		Namespace:  context.Namespace,
+		Extensions: describeExtensions(context.Extensions),
+		Cluster:    ClusterDescription{Name: context.Cluster},
+		User:       AuthInfoDescription{Name: context.AuthInfo},
+	}
+
+	// Tags are shown on their own, unless they can't be read
+	if tags, err := GetTags(context); err == nil && tags != nil {
+		description.Tags = tags
+		delete(description.Extensions, TagsExtension)
+	}
+
+	if cluster, exists := opts.Config.Clusters[context.Cluster]; exists {
+		description.Cluster = describeCluster(context.Cluster, cluster, now)
+		description.Cluster.Source = opts.ClusterSource(context.Cluster)
+	} else {
+		description.Cluster.Missing = true
+	}
+
+	if authInfo, exists := opts.Config.AuthInfos[context.AuthInfo]; exists {
+		description.User = describeAuthInfo(context.AuthInfo, authInfo, now)
+		description.User.Source = opts.AuthInfoSource(context.AuthInfo)
+	} else {
+		description.User.Missing = true
+	}
+
+	return description, nil
+}
+
+func describeCluster(name string, cluster *api.Cluster, now time.Time) ClusterDescription {
+	description := ClusterDescription{
+		Name:                  name,
+		Server:                cluster.Server,
+		TLSServerName:         cluster.TLSServerName,
+		InsecureSkipTLSVerify: cluster.InsecureSkipTLSVerify,
+		ProxyURL:              cluster.ProxyURL,
+		DisableCompression:    cluster.DisableCompression,
+		Extensions:            describeExtensions(cluster.Extensions),
+	}
+
+	switch {
+	case len(cluster.CertificateAuthorityData) > 0:
+		description.CertificateAuthority = "embedded"
+	case cluster.CertificateAuthority != "":
+		description.CertificateAuthority = ResolveFilePath(cluster.CertificateAuthority, cluster.LocationOfOrigin)
+	case !cluster.InsecureSkipTLSVerify:
+		description.CertificateAuthority = "system"
+	}
+
+	data, err := ClusterCertificateAuthority(cluster)
+	description.Certificates = describeCertificates(data, err, now)
+
+	return description
+}
+
+func describeAuthInfo(name string, authInfo *api.AuthInfo, now time.Time) AuthInfoDescription {
+	redacted := authInfo.DeepCopy()
+	RedactAuthInfo(redacted)
+
+	description := AuthInfoDescription{
+		Name:        name,
+		AuthMethod:  DescribeAuthMethod(authInfo),
+		Token:       redacted.Token,
+		TokenFile:   ResolveFilePath(authInfo.TokenFile, authInfo.LocationOfOrigin),
+		Username:    authInfo.Username,
+		Password:    redacted.Password,
+		Impersonate: authInfo.Impersonate,
+		Extensions:  describeExtensions(authInfo.Extensions),
+	}
+
+	if len(authInfo.ClientCertificateData) > 0 {
+		description.ClientCertificate = "embedded"
+	} else {
+		description.ClientCertificate = ResolveFilePath(authInfo.ClientCertificate, authInfo.LocationOfOrigin)
+	}
+	if len(authInfo.ClientKeyData) > 0 {
+		description.ClientKey = "embedded"
+	} else {
+		description.ClientKey = ResolveFilePath(authInfo.ClientKey, authInfo.LocationOfOrigin)
+	}
+
+	data, err := AuthInfoClientCertificate(authInfo)
+	description.Certificates = describeCertificates(data, err, now)
+
+	if redacted.Exec != nil {
+		description.Exec = &ExecDescription{
+			Command:         redacted.Exec.Command,
+			Args:            redacted.Exec.Args,
+			APIVersion:      redacted.Exec.APIVersion,
+			InteractiveMode: string(redacted.Exec.InteractiveMode),
+		}
+		for _, env := range redacted.Exec.Env {
+			description.Exec.Env = append(description.Exec.Env, env.Name+"="+env.Value)
+		}
+	}
+	if authInfo.AuthProvider != nil {
+		description.AuthProvider = authInfo.AuthProvider.Name
+	}
+
+	return description
+}
+
+func describeCertificates(data []byte, err error, now time.Time) []CertificateDescription {
+	// Nothing configured, nothing to describe
+	if err == nil && data == nil {
+		return nil
+	}
+
+	var certificates []*x509.Certificate
+	if err == nil {
+		certificates, err = ParseCertificates(data)
+	}
+	if err != nil {
+		return []CertificateDescription{{Error: err.Error()}}
+	}
+
+	var descriptions []CertificateDescription
+	for _, certificate := range certificates {
+		descriptions = append(descriptions, CertificateDescription{
+			Subject:       certificate.Subject.String(),
+			Issuer:        certificate.Issuer.String(),
+			NotAfter:      certificate.NotAfter,
+			DaysRemaining: DaysRemaining(certificate, now),
+			Problem:       ExpiryProblem(certificate, now),
+		})
+	}
+	return descriptions
+}
+
+// describeExtensions shows the raw value of every extension
+func describeExtensions(extensions map[string]runtime.Object) map[string]string {
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	described := map[string]string{}
+	for name, extension := range extensions {
+		described[name] = extensionValue(extension)
+	}
+	return described
+}
+
+// extensionValue returns the raw JSON of an extension as it appears in the kubeconfig
+func extensionValue(extension runtime.Object) string {
+	if unknown, ok := extension.(*runtime.Unknown); ok {
+		return string(unknown.Raw)
+	}
+	if raw, err := json.Marshal(extension); err == nil {
+		return string(raw)
+	}
+	return fmt.Sprintf("%T", extension)
+}
@@ -0,0 +1,155 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"strings"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/base64"
+
+	"k8s.io/client-go/tools/clientcmd"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// DiffOperation tells whether a line was kept, removed or added
+type DiffOperation byte
+
+const (
+	DiffKeep   DiffOperation = ' '
+	DiffRemove DiffOperation = '-'
+	DiffAdd    DiffOperation = '+'
+)
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	Operation DiffOperation
+	Text      string
+}
+
+// DiffLines computes a line-based diff using the longest common subsequence.
+// Kubeconfig files are small, so the quadratic approach is fine.
+func DiffLines(before []string, after []string) []DiffLine {
+	// common[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			lines = append(lines, DiffLine{DiffKeep, before[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, DiffLine{DiffRemove, before[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{DiffAdd, after[j]})
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		lines = append(lines, DiffLine{DiffRemove, before[i]})
+	}
+	for ; j < len(after); j++ {
+		lines = append(lines, DiffLine{DiffAdd, after[j]})
+	}
+
+	return lines
+}
+
+// PendingChanges diffs the configuration as it was loaded against the modified one, with secrets redacted.
+// It returns nil if nothing changed.
+func (opts *KubeConfigOptions) PendingChanges() ([]DiffLine, error) {
+	// Secrets are replaced by a fingerprint rather than a fixed value, so a changed secret still shows up.
+	// The key only lives for this diff, the fingerprints can't be matched against anything else.
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	before, err := clientcmd.Write(*fingerprintSecrets(opts.loaded, key))
+	if err != nil {
+		return nil, err
+	}
+	after, err := clientcmd.Write(*fingerprintSecrets(opts.Config, key))
+	if err != nil {
+		return nil, err
+	}
+
+	if string(before) == string(after) {
+		return nil, nil
+	}
+	return DiffLines(splitLines(before), splitLines(after)), nil
+}
+
+// fingerprintSecrets returns a copy of the configuration with every secret and certificate replaced by
+// a placeholder holding a short keyed fingerprint of its value, e.g. "REDACTED+1a2b3c4"
+func fingerprintSecrets(config *api.Config, key []byte) *api.Config {
+	fingerprint := func(value []byte) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(value)
+		return hex.EncodeToString(mac.Sum(nil))[:7]
+	}
+
+	// Data fields are written base64 encoded, so store the bytes which encode to a readable placeholder.
+	// The placeholder is a multiple of 4 base64 characters long, which makes it encode back exactly.
+	dataPlaceholder := func(data []byte) []byte {
+		placeholder, _ := base64.StdEncoding.DecodeString("DATA+OMITTED+" + fingerprint(data))
+		return placeholder
+	}
+
+	fingerprinted := config.DeepCopy()
+	for _, cluster := range fingerprinted.Clusters {
+		if len(cluster.CertificateAuthorityData) > 0 {
+			cluster.CertificateAuthorityData = dataPlaceholder(cluster.CertificateAuthorityData)
+		}
+	}
+	for _, authInfo := range fingerprinted.AuthInfos {
+		if len(authInfo.ClientCertificateData) > 0 {
+			authInfo.ClientCertificateData = dataPlaceholder(authInfo.ClientCertificateData)
+		}
+		if len(authInfo.ClientKeyData) > 0 {
+			authInfo.ClientKeyData = dataPlaceholder(authInfo.ClientKeyData)
+		}
+		redactAuthInfo(authInfo, func(secret string) string {
+			return Redacted + "+" + fingerprint([]byte(secret))
+		})
+	}
+	return fingerprinted
+}
+
+func splitLines(data []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
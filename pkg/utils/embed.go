@@ -0,0 +1,267 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ResolveFilePath resolves a path from a kubeconfig entry, relative paths are relative to the kubeconfig file they were loaded from.
+func ResolveFilePath(path string, origin string) string {
+	if path == "" || filepath.IsAbs(path) || origin == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(origin), path)
+}
+
+// ReadPEMFile reads a file and verifies that it holds PEM data of the expected type, e.g. "CERTIFICATE" or "PRIVATE KEY".
+func ReadPEMFile(path string, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidatePEM(data, blockType); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// ValidatePEM verifies that data holds at least one PEM block of the expected type. Other blocks are
+// allowed next to it, e.g. the "EC PARAMETERS" block OpenSSL writes in front of an "EC PRIVATE KEY".
+func ValidatePEM(data []byte, blockType string) error {
+	found := false
+	var others []string
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if strings.HasSuffix(block.Type, blockType) {
+			found = true
+		} else {
+			others = append(others, block.Type)
+		}
+	}
+
+	if !found {
+		if len(others) > 0 {
+			return fmt.Errorf("expected a %s PEM block, found %s", blockType, strings.Join(others, ", "))
+		}
+		return fmt.Errorf("no %s PEM data found", blockType)
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return fmt.Errorf("unexpected data after the last PEM block")
+	}
+	return nil
+}
+
+// EmbedCluster reads the certificate authority file of a cluster into the matching data field and clears the path.
+// It returns whether anything was embedded.
+func EmbedCluster(cluster *api.Cluster) (bool, error) {
+	if cluster.CertificateAuthority == "" {
+		return false, nil
+	}
+
+	data, err := ReadPEMFile(ResolveFilePath(cluster.CertificateAuthority, cluster.LocationOfOrigin), "CERTIFICATE")
+	if err != nil {
+		return false, err
+	}
+
+	cluster.CertificateAuthorityData = data
+	cluster.CertificateAuthority = ""
+	return true, nil
+}
+
+// EmbedAuthInfo reads the client certificate and key files of a user into the matching data fields and clears the paths.
+// It returns whether anything was embedded.
+func EmbedAuthInfo(authInfo *api.AuthInfo) (bool, error) {
+	var certificate, key []byte
+	var err error
+
+	// Read both files before changing anything, so a user is never left half embedded
+	if authInfo.ClientCertificate != "" {
+		certificate, err = ReadPEMFile(ResolveFilePath(authInfo.ClientCertificate, authInfo.LocationOfOrigin), "CERTIFICATE")
+		if err != nil {
+			return false, err
+		}
+	}
+	if authInfo.ClientKey != "" {
+		key, err = ReadPEMFile(ResolveFilePath(authInfo.ClientKey, authInfo.LocationOfOrigin), "PRIVATE KEY")
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if certificate != nil {
+		authInfo.ClientCertificateData = certificate
+		authInfo.ClientCertificate = ""
+	}
+	if key != nil {
+		authInfo.ClientKeyData = key
+		authInfo.ClientKey = ""
+	}
+	return certificate != nil || key != nil, nil
+}
+
+// ExtractCluster writes the embedded certificate authority of a cluster to a file in the given directory and references it by path.
+// It returns whether anything was extracted.
+func ExtractCluster(name string, cluster *api.Cluster, directory string, files *ManagedFiles) (bool, error) {
+	if len(cluster.CertificateAuthorityData) == 0 {
+		return false, nil
+	}
+
+	entryDirectory, err := managedDirectory(directory, "clusters", name)
+	if err != nil {
+		return false, err
+	}
+
+	path, err := files.Write(entryDirectory, "ca.crt", cluster.CertificateAuthorityData)
+	if err != nil {
+		return false, err
+	}
+
+	cluster.CertificateAuthority = path
+	cluster.CertificateAuthorityData = nil
+	return true, nil
+}
+
+// ExtractAuthInfo writes the embedded client certificate and key of a user to files in the given directory and references them by path.
+// It returns whether anything was extracted.
+func ExtractAuthInfo(name string, authInfo *api.AuthInfo, directory string, files *ManagedFiles) (bool, error) {
+	extracted := false
+
+	entryDirectory, err := managedDirectory(directory, "users", name)
+	if err != nil {
+		return false, err
+	}
+
+	if len(authInfo.ClientCertificateData) > 0 {
+		path, err := files.Write(entryDirectory, "client.crt", authInfo.ClientCertificateData)
+		if err != nil {
+			return false, err
+		}
+
+		authInfo.ClientCertificate = path
+		authInfo.ClientCertificateData = nil
+		extracted = true
+	}
+
+	if len(authInfo.ClientKeyData) > 0 {
+		path, err := files.Write(entryDirectory, "client.key", authInfo.ClientKeyData)
+		if err != nil {
+			return false, err
+		}
+
+		authInfo.ClientKey = path
+		authInfo.ClientKeyData = nil
+		extracted = true
+	}
+
+	return extracted, nil
+}
+
+// managedDirectory returns the directory holding the files of a single entry.
+// Entry names can contain anything, so path separators are replaced and the result is checked to stay inside the directory.
+func managedDirectory(directory string, kind string, name string) (string, error) {
+	safeName := strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	if safeName == "" || safeName == "." || safeName == ".." {
+		return "", fmt.Errorf("%q can't be used as a directory name", name)
+	}
+
+	// Different names must never end up in the same directory, e.g. "a/b" and "a_b"
+	if safeName != name {
+		sum := sha256.Sum256([]byte(name))
+		safeName = fmt.Sprintf("%s-%x", safeName, sum[:4])
+	}
+
+	parent := filepath.Join(directory, kind)
+	entryDirectory := filepath.Join(parent, safeName)
+	if relative, err := filepath.Rel(parent, entryDirectory); err != nil || relative != safeName {
+		return "", fmt.Errorf("%q would be written outside of %s", name, parent)
+	}
+	return entryDirectory, nil
+}
+
+// ManagedFiles keeps track of the certificate and key files written while extracting,
+// so they can be rolled back when the kubeconfig referencing them couldn't be saved.
+type ManagedFiles struct {
+	written []managedFile
+}
+
+type managedFile struct {
+	path     string
+	previous []byte // Contents before they were overwritten, nil for a new file
+}
+
+// Write writes data to a file only readable by the current user and returns its absolute path.
+// In dry-run mode only the path is returned, so the change to the kubeconfig can still be shown.
+func (files *ManagedFiles) Write(directory string, name string, data []byte) (string, error) {
+	path, err := filepath.Abs(filepath.Join(directory, name))
+	if err != nil {
+		return "", err
+	}
+	if dryRun {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return "", err
+	}
+
+	// Remember what was there, a previous extract may have written the same file
+	previous, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	files.written = append(files.written, managedFile{path: path, previous: previous})
+	return path, nil
+}
+
+// Rollback restores the files written so far to how they were, removing the ones which didn't exist yet.
+func (files *ManagedFiles) Rollback() error {
+	var errs []error
+	for i := len(files.written) - 1; i >= 0; i-- {
+		file := files.written[i]
+		if file.previous != nil {
+			errs = append(errs, os.WriteFile(file.path, file.previous, 0600))
+			continue
+		}
+
+		errs = append(errs, os.Remove(file.path))
+		// Only succeeds when the directory is empty, which is fine
+		os.Remove(filepath.Dir(file.path))
+	}
+	files.written = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"os"
+	"fmt"
+	"strings"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ExtractContexts builds a self-contained configuration holding only the given contexts and their clusters and users.
+// Referenced certificate, key and token files are inlined, credentials are left out entirely when stripCredentials is set.
+func (opts *KubeConfigOptions) ExtractContexts(names []string, stripCredentials bool) (*api.Config, error) {
+	config := api.NewConfig()
+
+	for _, name := range names {
+		context, exists := opts.Config.Contexts[name]
+		if !exists {
+			return nil, fmt.Errorf("context %q does not exist", name)
+		}
+		config.Contexts[name] = context.DeepCopy()
+
+		if cluster, exists := opts.Config.Clusters[context.Cluster]; exists {
+			config.Clusters[context.Cluster] = cluster.DeepCopy()
+		}
+
+		if authInfo, exists := opts.Config.AuthInfos[context.AuthInfo]; exists {
+			if stripCredentials {
+				config.AuthInfos[context.AuthInfo] = api.NewAuthInfo()
+			} else {
+				config.AuthInfos[context.AuthInfo] = authInfo.DeepCopy()
+			}
+		}
+	}
+
+	// Read every referenced file into the matching *-data field
+	if err := api.FlattenConfig(config); err != nil {
+		return nil, err
+	}
+
+	// Token files have no data field, the token itself takes their place
+	for name, authInfo := range config.AuthInfos {
+		if authInfo.TokenFile == "" {
+			continue
+		}
+		token, err := os.ReadFile(ResolveFilePath(authInfo.TokenFile, authInfo.LocationOfOrigin))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the token file of user %q: %w", name, err)
+		}
+		authInfo.Token = strings.TrimSpace(string(token))
+		authInfo.TokenFile = ""
+	}
+
+	// The exported file no longer has anything to do with the files it was loaded from
+	for _, context := range config.Contexts {
+		context.LocationOfOrigin = ""
+	}
+	for _, cluster := range config.Clusters {
+		cluster.LocationOfOrigin = ""
+	}
+	for _, authInfo := range config.AuthInfos {
+		authInfo.LocationOfOrigin = ""
+	}
+
+	if len(names) > 0 {
+		config.CurrentContext = names[0]
+	}
+
+	return config, nil
+}
@@ -0,0 +1,364 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// JournalOptions configures the journal of changes made to the kubeconfig
+type JournalOptions struct {
+	Path    string // Journal file, empty disables the journal
+	Command string // Command which made the changes
+}
+
+var journalOptions JournalOptions
+
+// SetJournalOptions configures the journal written by WriteConfig
+func SetJournalOptions(options JournalOptions) {
+	journalOptions = options
+}
+
+// Number of operations kept in the journal
+const journalRetention = 100
+
+// Kinds of entries tracked by the journal
+const (
+	JournalContext        = "context"
+	JournalCluster        = "cluster"
+	JournalAuthInfo       = "user"
+	JournalCurrentContext = "current-context"
+)
+
+// JournalEntry records a single operation, e.g. a switch or a delete
+type JournalEntry struct {
+	ID      int             `json:"id"`
+	Time    time.Time       `json:"time"`
+	Command string          `json:"command"`
+	Changes []JournalChange `json:"changes"`
+	Undone  bool            `json:"undone,omitempty"`
+}
+
+// JournalChange records the before and after value of a single entry.
+// Contexts, clusters and users are stored as a kubeconfig holding only that entry, the current context by name.
+// An empty value means the entry didn't exist.
+type JournalChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name,omitempty"`
+	Origin string `json:"origin,omitempty"` // File the entry was loaded from or written to
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// String returns a human readable description of the change
+func (change JournalChange) String() string {
+	if change.Kind == JournalCurrentContext {
+		return fmt.Sprintf("switched from %q to %q", change.Before, change.After)
+	}
+
+	switch {
+	case change.Before == "":
+		return fmt.Sprintf("added %s %q", change.Kind, change.Name)
+	case change.After == "":
+		return fmt.Sprintf("removed %s %q", change.Kind, change.Name)
+	default:
+		return fmt.Sprintf("changed %s %q", change.Kind, change.Name)
+	}
+}
+
+// Summary returns a human readable description of all changes in the entry
+func (entry JournalEntry) Summary() string {
+	var changes []string
+	for _, change := range entry.Changes {
+		changes = append(changes, change.String())
+	}
+	return strings.Join(changes, ", ")
+}
+
+// ReadJournal returns the journaled operations, oldest first.
+func ReadJournal() ([]JournalEntry, error) {
+	data, err := os.ReadFile(journalOptions.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("journal %s is corrupt: %w", journalOptions.Path, err)
+	}
+	return entries, nil
+}
+
+func writeJournal(entries []JournalEntry) error {
+	if len(entries) > journalRetention {
+		entries = entries[len(entries)-journalRetention:]
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(journalOptions.Path), 0700); err != nil {
+		return err
+	}
+	// The journal holds credentials, just like the kubeconfig itself
+	return os.WriteFile(journalOptions.Path, data, 0600)
+}
+
+// recordJournalEntry journals what changed since the configuration was loaded or last written.
+// It returns nil if the journal is disabled or nothing changed.
+func (opts *KubeConfigOptions) recordJournalEntry() (*JournalEntry, error) {
+	if journalOptions.Path == "" || opts.loaded == nil {
+		return nil, nil
+	}
+
+	changes, err := diffForJournal(opts.loaded, opts.Config)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := JournalEntry{ID: 1, Time: time.Now(), Command: journalOptions.Command, Changes: changes}
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+
+	return &entry, writeJournal(append(entries, entry))
+}
+
+// diffForJournal records every context, cluster and user which differs between both configurations
+func diffForJournal(before *api.Config, after *api.Config) ([]JournalChange, error) {
+	var changes []JournalChange
+
+	for _, kind := range []string{JournalContext, JournalCluster, JournalAuthInfo} {
+		names := append(journalEntryNames(before, kind), journalEntryNames(after, kind)...)
+		slices.Sort(names)
+
+		for _, name := range slices.Compact(names) {
+			beforeValue, err := journalSnapshot(before, kind, name)
+			if err != nil {
+				return nil, err
+			}
+			afterValue, err := journalSnapshot(after, kind, name)
+			if err != nil {
+				return nil, err
+			}
+
+			if beforeValue == afterValue {
+				continue
+			}
+
+			origin := journalOrigin(after, kind, name)
+			if origin == "" {
+				origin = journalOrigin(before, kind, name)
+			}
+			changes = append(changes, JournalChange{Kind: kind, Name: name, Origin: origin, Before: beforeValue, After: afterValue})
+		}
+	}
+
+	if before.CurrentContext != after.CurrentContext {
+		changes = append(changes, JournalChange{Kind: JournalCurrentContext, Before: before.CurrentContext, After: after.CurrentContext})
+	}
+
+	return changes, nil
+}
+
+func journalEntryNames(config *api.Config, kind string) []string {
+	switch kind {
+	case JournalContext:
+		return SortedKeys(config.Contexts)
+	case JournalCluster:
+		return SortedKeys(config.Clusters)
+	default:
+		return SortedKeys(config.AuthInfos)
+	}
+}
+
+func journalOrigin(config *api.Config, kind string, name string) string {
+	switch kind {
+	case JournalContext:
+		if context, exists := config.Contexts[name]; exists {
+			return context.LocationOfOrigin
+		}
+	case JournalCluster:
+		if cluster, exists := config.Clusters[name]; exists {
+			return cluster.LocationOfOrigin
+		}
+	default:
+		if authInfo, exists := config.AuthInfos[name]; exists {
+			return authInfo.LocationOfOrigin
+		}
+	}
+	return ""
+}
+
+// journalSnapshot serializes a single entry as a kubeconfig, so values can be compared and stored the way they're written
+func journalSnapshot(config *api.Config, kind string, name string) (string, error) {
+	snapshot := api.NewConfig()
+	switch kind {
+	case JournalContext:
+		context, exists := config.Contexts[name]
+		if !exists {
+			return "", nil
+		}
+		snapshot.Contexts[name] = context
+	case JournalCluster:
+		cluster, exists := config.Clusters[name]
+		if !exists {
+			return "", nil
+		}
+		snapshot.Clusters[name] = cluster
+	default:
+		authInfo, exists := config.AuthInfos[name]
+		if !exists {
+			return "", nil
+		}
+		snapshot.AuthInfos[name] = authInfo
+	}
+
+	data, err := clientcmd.Write(*snapshot)
+	return string(data), err
+}
+
+// UndoJournalEntry reverts the operation with the given ID.
+// Entries changed since the operation are left alone, the undo is refused instead so those changes aren't lost.
+func (opts *KubeConfigOptions) UndoJournalEntry(id int) (*JournalEntry, error) {
+	entries, err := ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(entries, func(entry JournalEntry) bool { return entry.ID == id })
+	if index == -1 {
+		return nil, fmt.Errorf("could not find operation %d in the journal", id)
+	}
+	entry := entries[index]
+	if entry.Undone {
+		return nil, fmt.Errorf("operation %d was undone already", id)
+	}
+
+	// Only revert entries which still look exactly like the operation left them
+	var conflicts []string
+	for _, change := range entry.Changes {
+		current := opts.Config.CurrentContext
+		if change.Kind != JournalCurrentContext {
+			current, err = journalSnapshot(opts.Config, change.Kind, change.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if current != change.After {
+			conflicts = append(conflicts, fmt.Sprintf("%s %q", change.Kind, change.Name))
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%s changed since operation %d, undoing it would overwrite those changes", strings.Join(conflicts, ", "), id)
+	}
+
+	sourceFiles := opts.GetSourceFiles()
+	for _, change := range entry.Changes {
+		if err := opts.revertJournalChange(change, sourceFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	// The configuration now holds the reverted entries, so the caller can show what would change
+	if dryRun {
+		return &entry, ErrDryRun
+	}
+
+	if err := opts.WriteConfig(); err != nil {
+		return nil, err
+	}
+
+	// Mark the operation as undone, reading the journal again as writing added the undo itself
+	entries, err = ReadJournal()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			entries[i].Undone = true
+		}
+	}
+
+	return &entry, writeJournal(entries)
+}
+
+// revertJournalChange puts the value from before the change back, in the file it came from if that's still loaded
+func (opts *KubeConfigOptions) revertJournalChange(change JournalChange, sourceFiles []string) error {
+	if change.Kind == JournalCurrentContext {
+		opts.Config.CurrentContext = change.Before
+		return nil
+	}
+
+	previous := api.NewConfig()
+	if change.Before != "" {
+		var err error
+		previous, err = clientcmd.Load([]byte(change.Before))
+		if err != nil {
+			return fmt.Errorf("journal entry for %s %q is corrupt: %w", change.Kind, change.Name, err)
+		}
+	}
+
+	origin := ""
+	if slices.Contains(sourceFiles, change.Origin) {
+		origin = change.Origin
+	}
+
+	switch change.Kind {
+	case JournalContext:
+		delete(opts.Config.Contexts, change.Name)
+		if context, exists := previous.Contexts[change.Name]; exists {
+			context.LocationOfOrigin = origin
+			opts.Config.Contexts[change.Name] = context
+		}
+	case JournalCluster:
+		delete(opts.Config.Clusters, change.Name)
+		if cluster, exists := previous.Clusters[change.Name]; exists {
+			cluster.LocationOfOrigin = origin
+			opts.Config.Clusters[change.Name] = cluster
+		}
+	default:
+		delete(opts.Config.AuthInfos, change.Name)
+		if authInfo, exists := previous.AuthInfos[change.Name]; exists {
+			authInfo.LocationOfOrigin = origin
+			opts.Config.AuthInfos[change.Name] = authInfo
+		}
+	}
+	return nil
+}
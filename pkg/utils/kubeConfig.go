@@ -19,6 +19,8 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"k8s.io/client-go/kubernetes"
@@ -33,21 +35,51 @@ type KubeConfigOptions struct {
 	Contexts       []string
 	CurrentContext string
 
-	Config *api.Config
-	Client *kubernetes.Clientset
+	Config       *api.Config
+	ConfigAccess *clientcmd.PathOptions
+	Client       *kubernetes.Clientset
+
+	// Configuration as it was loaded, to find out what changed when writing
+	loaded *api.Config
 }
 
-func (opts *KubeConfigOptions) Init(kubeConfigPath string) {
-	// Load kube config file
+// NewConfigAccess returns the access layer used to both read and write the kubeconfig.
+// An explicit path takes precedence, otherwise the KUBECONFIG file chain or ~/.kube/config is used.
+func NewConfigAccess(kubeConfigPath string) *clientcmd.PathOptions {
+	configAccess := clientcmd.NewDefaultPathOptions()
+	configAccess.LoadingRules.ExplicitPath = kubeConfigPath
+
+	return configAccess
+}
+
+// Load only reads the kubeconfig, for commands which never talk to a cluster.
+func (opts *KubeConfigOptions) Load(kubeConfigPath string) {
+	opts.ConfigAccess = NewConfigAccess(kubeConfigPath)
+
+	// Load kube config file(s), keeping track of the file every entry originates from
 	var err error
-	opts.Config, err = clientcmd.LoadFromFile(kubeConfigPath)
+	opts.Config, err = opts.ConfigAccess.GetStartingConfig()
 	if err != nil {
+		opts.Config = nil
 		logHandler.Handle(logger.ErrInitKubeconfig, err)
 		return
 	}
+	opts.loaded = opts.Config.DeepCopy()
+}
+
+// Init reads the kubeconfig and creates a client for the current context.
+func (opts *KubeConfigOptions) Init(kubeConfigPath string) {
+	opts.Load(kubeConfigPath)
+	if opts.Config == nil {
+		return
+	}
+
+	// Build client-usable configuration from the same file(s), resolving relative paths this time
+	loadingRules := *opts.ConfigAccess.LoadingRules
+	loadingRules.Precedence = opts.ConfigAccess.GetLoadingPrecedence()
+	loadingRules.DoNotResolvePaths = false
 
-	// Build client-usable configuration from file
-	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(&loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
 	if err != nil {
 		logHandler.Handle(logger.ErrAPIEndpoint, err)
 		return
@@ -62,7 +94,10 @@ func (opts *KubeConfigOptions) Init(kubeConfigPath string) {
 }
 
 func (opts *KubeConfigOptions) InitOrCreate(kubeConfigPath string) {
-	_, err := os.Stat(kubeConfigPath)
+	opts.ConfigAccess = NewConfigAccess(kubeConfigPath)
+	defaultFilename := opts.ConfigAccess.GetDefaultFilename()
+
+	_, err := os.Stat(defaultFilename)
 	if os.IsNotExist(err) {
 		// If kubeconfig file doesn't exist, create an empty config
 		opts.Config = &api.Config{
@@ -73,19 +108,109 @@ func (opts *KubeConfigOptions) InitOrCreate(kubeConfigPath string) {
 			Clusters:   map[string]*api.Cluster{},
 		}
 
-		// No Kubeconfig was present, so we create one with the new data
-		err := clientcmd.WriteToFile(*opts.Config, kubeConfigPath)
+		// No Kubeconfig was present, so we create one with the new data, unless nothing may be written
+		if !dryRun {
+			err := clientcmd.WriteToFile(*opts.Config, defaultFilename)
+			if err != nil {
+				logHandler.Handle(logger.ErrWriteKubeconfig, err)
+				return
+			}
+		}
+
+		// Reload so entries from other files in the chain are picked up as well
+		opts.Config, err = opts.ConfigAccess.GetStartingConfig()
 		if err != nil {
-			logHandler.Handle(logger.ErrWriteKubeconfig, err)
+			logHandler.Handle(logger.ErrInitKubeconfig, err)
 			return
 		}
+		opts.loaded = opts.Config.DeepCopy()
 	} else if err != nil {
 		logHandler.Handle(logger.ErrInitKubeconfig, err)
 		return
 	} else {
 		// Load kubeconfig from file
-		opts.Init(kubeConfigPath)
+		opts.Load(kubeConfigPath)
+	}
+}
+
+// Nothing is written in dry-run mode, so commands can show what they would change instead
+var dryRun bool
+
+// ErrDryRun is returned when trying to write in dry-run mode
+var ErrDryRun = errors.New("running in dry-run mode, nothing was written")
+
+// SetDryRun enables or disables dry-run mode
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// WriteConfig persists the modified configuration through the config access layer.
+// Every context, cluster and user is written back to the file it was loaded from, new entries go to the default file.
+// The files are backed up first, so any write can be undone with the restore command, and the changes are journaled for undo.
+func (opts *KubeConfigOptions) WriteConfig() error {
+	if dryRun {
+		return ErrDryRun
+	}
+
+	if _, err := opts.CreateBackup(); err != nil {
+		return fmt.Errorf("failed to back up the kubeconfig: %w", err)
+	}
+
+	if err := clientcmd.ModifyConfig(opts.ConfigAccess, *opts.Config, true); err != nil {
+		return err
+	}
+
+	// The write itself succeeded, so a journal failure is no reason to report it as failed
+	if _, err := opts.recordJournalEntry(); err != nil {
+		logHandler.Handle(logger.ErrorType{
+			Level:   logger.Warning,
+			Message: "Failed to record the change in the journal, it can't be undone with the undo command",
+		}, err)
+	}
+	opts.loaded = opts.Config.DeepCopy()
+
+	return nil
+}
+
+// GetSourceFiles returns the existing kubeconfig files the configuration was merged from, in loading order.
+func (opts *KubeConfigOptions) GetSourceFiles() []string {
+	var files []string
+	for _, file := range opts.ConfigAccess.GetLoadingPrecedence() {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// IsMultiFile returns true if the configuration was merged from more than one kubeconfig file.
+func (opts *KubeConfigOptions) IsMultiFile() bool {
+	return len(opts.GetSourceFiles()) > 1
+}
+
+// ContextSource returns the file the given context was loaded from, or the file it will be written to if it is new.
+func (opts *KubeConfigOptions) ContextSource(name string) string {
+	if context, exists := opts.Config.Contexts[name]; exists && context.LocationOfOrigin != "" {
+		return context.LocationOfOrigin
+	}
+	return opts.ConfigAccess.GetDefaultFilename()
+}
+
+// ClusterSource returns the file the given cluster was loaded from, or the file it will be written to if it is new.
+func (opts *KubeConfigOptions) ClusterSource(name string) string {
+	if cluster, exists := opts.Config.Clusters[name]; exists && cluster.LocationOfOrigin != "" {
+		return cluster.LocationOfOrigin
+	}
+	return opts.ConfigAccess.GetDefaultFilename()
+}
+
+// AuthInfoSource returns the file the given user was loaded from, or the file it will be written to if it is new.
+func (opts *KubeConfigOptions) AuthInfoSource(name string) string {
+	if authInfo, exists := opts.Config.AuthInfos[name]; exists && authInfo.LocationOfOrigin != "" {
+		return authInfo.LocationOfOrigin
 	}
+	return opts.ConfigAccess.GetDefaultFilename()
 }
 
 func (opts *KubeConfigOptions) GetContexts() {
@@ -0,0 +1,206 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// LintRule identifies a single health or security check
+type LintRule string
+
+const (
+	LintFilePermissions LintRule = "file-permissions"
+	LintInsecureTLS     LintRule = "insecure-skip-tls-verify"
+	LintPlainHTTP       LintRule = "plain-http"
+	LintStaticSecret    LintRule = "static-credentials"
+	LintAuthProvider    LintRule = "deprecated-auth-provider"
+	LintDuplicateServer LintRule = "duplicate-server"
+	LintEmptyNamespace  LintRule = "empty-namespace"
+)
+
+// LintRules lists the rules in the order they are reported
+var LintRules = []LintRule{LintFilePermissions, LintInsecureTLS, LintPlainHTTP, LintStaticSecret, LintAuthProvider, LintDuplicateServer, LintEmptyNamespace}
+
+// Description returns a human readable title for the rule
+func (rule LintRule) Description() string {
+	switch rule {
+	case LintFilePermissions:
+		return "Kubeconfig files readable by group or others"
+	case LintInsecureTLS:
+		return "Clusters skipping TLS verification"
+	case LintPlainHTTP:
+		return "Clusters reached over plain HTTP"
+	case LintStaticSecret:
+		return "Static tokens or passwords stored in the kubeconfig"
+	case LintAuthProvider:
+		return "Users relying on the deprecated auth-provider mechanism"
+	case LintDuplicateServer:
+		return "Servers configured under multiple cluster names"
+	case LintEmptyNamespace:
+		return "Contexts without a namespace"
+	default:
+		return string(rule)
+	}
+}
+
+// LintFinding describes a single problem found in the kubeconfig
+type LintFinding struct {
+	Rule    LintRule `json:"rule"`
+	Kind    string   `json:"kind"` // "file", "context", "cluster" or "user"
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Message string   `json:"message"`
+}
+
+// Lint checks the configuration and the files it was loaded from for health and security problems.
+func (opts *KubeConfigOptions) Lint() []LintFinding {
+	var findings []LintFinding
+
+	// Permission bits don't mean the same thing on Windows
+	if runtime.GOOS != "windows" {
+		for _, file := range opts.GetSourceFiles() {
+			info, err := os.Stat(file)
+			if err != nil || info.Mode().Perm()&0077 == 0 {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Rule:    LintFilePermissions,
+				Kind:    "file",
+				Name:    file,
+				File:    file,
+				Message: fmt.Sprintf("%s has mode %s, anyone in its group or others can read your credentials (fix with chmod 600)", file, info.Mode().Perm()),
+			})
+		}
+	}
+
+	servers := map[string][]string{}
+	for _, name := range SortedKeys(opts.Config.Clusters) {
+		cluster := opts.Config.Clusters[name]
+		file := opts.ClusterSource(name)
+
+		if cluster.InsecureSkipTLSVerify {
+			findings = append(findings, LintFinding{
+				Rule:    LintInsecureTLS,
+				Kind:    "cluster",
+				Name:    name,
+				File:    file,
+				Message: fmt.Sprintf("cluster %q skips TLS verification, used by %s", name, describeReferences(opts.GetClusterReferences(name))),
+			})
+		}
+
+		if server, err := url.Parse(cluster.Server); err == nil && strings.EqualFold(server.Scheme, "http") {
+			findings = append(findings, LintFinding{
+				Rule:    LintPlainHTTP,
+				Kind:    "cluster",
+				Name:    name,
+				File:    file,
+				Message: fmt.Sprintf("cluster %q is reached over plain HTTP (%s)", name, cluster.Server),
+			})
+		}
+
+		if cluster.Server != "" {
+			server := strings.TrimSuffix(strings.ToLower(cluster.Server), "/")
+			servers[server] = append(servers[server], name)
+		}
+	}
+
+	for _, server := range SortedKeys(servers) {
+		names := servers[server]
+		if len(names) < 2 {
+			continue
+		}
+		for _, name := range names {
+			findings = append(findings, LintFinding{
+				Rule:    LintDuplicateServer,
+				Kind:    "cluster",
+				Name:    name,
+				File:    opts.ClusterSource(name),
+				Message: fmt.Sprintf("cluster %q points at %s, just like %s", name, server, describeOthers(names, name)),
+			})
+		}
+	}
+
+	for _, name := range SortedKeys(opts.Config.AuthInfos) {
+		authInfo := opts.Config.AuthInfos[name]
+		file := opts.AuthInfoSource(name)
+
+		var secrets []string
+		if authInfo.Token != "" {
+			secrets = append(secrets, "token")
+		}
+		if authInfo.Password != "" {
+			secrets = append(secrets, "password")
+		}
+		if len(secrets) > 0 {
+			findings = append(findings, LintFinding{
+				Rule:    LintStaticSecret,
+				Kind:    "user",
+				Name:    name,
+				File:    file,
+				Message: fmt.Sprintf("user %q stores a static %s in the kubeconfig, prefer short-lived credentials from an exec plugin", name, strings.Join(secrets, " and ")),
+			})
+		}
+
+		if authInfo.AuthProvider != nil {
+			findings = append(findings, LintFinding{
+				Rule:    LintAuthProvider,
+				Kind:    "user",
+				Name:    name,
+				File:    file,
+				Message: fmt.Sprintf("user %q uses the deprecated %q auth-provider, switch to an exec credential plugin", name, authInfo.AuthProvider.Name),
+			})
+		}
+	}
+
+	for _, name := range SortedKeys(opts.Config.Contexts) {
+		if opts.Config.Contexts[name].Namespace == "" {
+			findings = append(findings, LintFinding{
+				Rule:    LintEmptyNamespace,
+				Kind:    "context",
+				Name:    name,
+				File:    opts.ContextSource(name),
+				Message: fmt.Sprintf("context %q has no namespace, so commands silently end up in the default namespace", name),
+			})
+		}
+	}
+
+	return findings
+}
+
+func describeReferences(contexts []string) string {
+	if len(contexts) == 0 {
+		return "no context"
+	}
+	return fmt.Sprintf("context(s) %s", strings.Join(contexts, ", "))
+}
+
+func describeOthers(names []string, name string) string {
+	var others []string
+	for _, other := range names {
+		if other != name {
+			others = append(others, fmt.Sprintf("%q", other))
+		}
+	}
+	return strings.Join(others, ", ")
+}
@@ -0,0 +1,169 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// PruneCategory groups the broken entries found in a kubeconfig
+type PruneCategory int
+
+const (
+	DanglingContext PruneCategory = iota
+	UnusedCluster
+	UnusedAuthInfo
+	MissingFile
+)
+
+// PruneCategories lists the categories in the order they are reported
+var PruneCategories = []PruneCategory{DanglingContext, UnusedCluster, UnusedAuthInfo, MissingFile}
+
+// String returns a human readable title for the category
+func (c PruneCategory) String() string {
+	switch c {
+	case DanglingContext:
+		return "Contexts pointing at a missing cluster or user"
+	case UnusedCluster:
+		return "Clusters not used by any context"
+	case UnusedAuthInfo:
+		return "Users not used by any context"
+	case MissingFile:
+		return "Certificate and key files which no longer exist"
+	default:
+		return "Unknown"
+	}
+}
+
+// PruneFinding describes a single broken entry in the kubeconfig
+type PruneFinding struct {
+	Category PruneCategory
+	Kind     string // "context", "cluster" or "user"
+	Name     string
+	Field    string // Only set for missing files, e.g. "certificate-authority"
+	Message  string
+}
+
+// FindPrunableEntries scans the configuration for dangling contexts, unused clusters and users, and missing files.
+func (opts *KubeConfigOptions) FindPrunableEntries() []PruneFinding {
+	var findings []PruneFinding
+
+	// A context without a cluster or user is valid, e.g. one relying on in-cluster configuration
+	for _, name := range SortedKeys(opts.Config.Contexts) {
+		context := opts.Config.Contexts[name]
+		if _, exists := opts.Config.Clusters[context.Cluster]; context.Cluster != "" && !exists {
+			findings = append(findings, PruneFinding{
+				Category: DanglingContext,
+				Kind:     "context",
+				Name:     name,
+				Message:  fmt.Sprintf("context %q references missing cluster %q", name, context.Cluster),
+			})
+			continue
+		}
+		if _, exists := opts.Config.AuthInfos[context.AuthInfo]; context.AuthInfo != "" && !exists {
+			findings = append(findings, PruneFinding{
+				Category: DanglingContext,
+				Kind:     "context",
+				Name:     name,
+				Message:  fmt.Sprintf("context %q references missing user %q", name, context.AuthInfo),
+			})
+		}
+	}
+
+	for _, name := range SortedKeys(opts.Config.Clusters) {
+		if len(opts.GetClusterReferences(name)) == 0 {
+			findings = append(findings, PruneFinding{
+				Category: UnusedCluster,
+				Kind:     "cluster",
+				Name:     name,
+				Message:  fmt.Sprintf("cluster %q is not used by any context", name),
+			})
+		}
+
+		cluster := opts.Config.Clusters[name]
+		if missing := missingFile(cluster.CertificateAuthority, cluster.LocationOfOrigin); missing != "" {
+			findings = append(findings, PruneFinding{
+				Category: MissingFile,
+				Kind:     "cluster",
+				Name:     name,
+				Field:    "certificate-authority",
+				Message:  fmt.Sprintf("cluster %q references missing certificate authority %q", name, missing),
+			})
+		}
+	}
+
+	for _, name := range SortedKeys(opts.Config.AuthInfos) {
+		if len(opts.GetAuthInfoReferences(name)) == 0 {
+			findings = append(findings, PruneFinding{
+				Category: UnusedAuthInfo,
+				Kind:     "user",
+				Name:     name,
+				Message:  fmt.Sprintf("user %q is not used by any context", name),
+			})
+		}
+
+		authInfo := opts.Config.AuthInfos[name]
+		if missing := missingFile(authInfo.ClientCertificate, authInfo.LocationOfOrigin); missing != "" {
+			findings = append(findings, PruneFinding{
+				Category: MissingFile,
+				Kind:     "user",
+				Name:     name,
+				Field:    "client-certificate",
+				Message:  fmt.Sprintf("user %q references missing client certificate %q", name, missing),
+			})
+		}
+		if missing := missingFile(authInfo.ClientKey, authInfo.LocationOfOrigin); missing != "" {
+			findings = append(findings, PruneFinding{
+				Category: MissingFile,
+				Kind:     "user",
+				Name:     name,
+				Field:    "client-key",
+				Message:  fmt.Sprintf("user %q references missing client key %q", name, missing),
+			})
+		}
+	}
+
+	return findings
+}
+
+// missingFile returns the resolved path if the referenced file doesn't exist
+func missingFile(path string, origin string) string {
+	if path == "" {
+		return ""
+	}
+
+	path = ResolveFilePath(path, origin)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+	return ""
+}
+
+// SortedKeys returns the keys of a map in alphabetical order
+func SortedKeys[T any](entries map[string]T) []string {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
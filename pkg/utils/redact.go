@@ -0,0 +1,94 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"strings"
+
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Value shown instead of a secret
+const Redacted = "REDACTED"
+
+// RedactConfig returns a copy of the configuration which is safe to show, without tokens, passwords, keys or other secrets.
+// Certificate data is shortened as well, as it's unreadable anyway.
+func RedactConfig(config *api.Config) *api.Config {
+	redacted := config.DeepCopy()
+	api.ShortenConfig(redacted)
+
+	for _, authInfo := range redacted.AuthInfos {
+		RedactAuthInfo(authInfo)
+	}
+	return redacted
+}
+
+// RedactAuthInfo replaces every secret of a user in place.
+func RedactAuthInfo(authInfo *api.AuthInfo) {
+	redactAuthInfo(authInfo, func(string) string { return Redacted })
+}
+
+// redactAuthInfo replaces every secret of a user in place by the placeholder returned for it
+func redactAuthInfo(authInfo *api.AuthInfo, placeholder func(secret string) string) {
+	if authInfo.Token != "" {
+		authInfo.Token = placeholder(authInfo.Token)
+	}
+	if authInfo.Password != "" {
+		authInfo.Password = placeholder(authInfo.Password)
+	}
+
+	// Auth providers keep their tokens and client secrets in their configuration
+	if authInfo.AuthProvider != nil {
+		for key, value := range authInfo.AuthProvider.Config {
+			if isSecretName(key) {
+				authInfo.AuthProvider.Config[key] = placeholder(value)
+			}
+		}
+	}
+
+	// Exec plugins can be handed secrets as environment variables or flags, e.g. --client-secret
+	if authInfo.Exec != nil {
+		for i := range authInfo.Exec.Env {
+			authInfo.Exec.Env[i].Value = placeholder(authInfo.Exec.Env[i].Value)
+		}
+		for i, arg := range authInfo.Exec.Args {
+			name, value, hasValue := strings.Cut(arg, "=")
+			if !strings.HasPrefix(name, "-") || !isSecretName(name) {
+				continue
+			}
+
+			if hasValue {
+				authInfo.Exec.Args[i] = name + "=" + placeholder(value)
+			} else if i+1 < len(authInfo.Exec.Args) {
+				authInfo.Exec.Args[i+1] = placeholder(authInfo.Exec.Args[i+1])
+			}
+		}
+	}
+}
+
+// isSecretName returns true if a setting with this name likely holds a secret
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	for _, secret := range []string{"token", "secret", "password", "key"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GetClusterReferences returns the sorted names of the contexts referencing the given cluster.
+func (opts *KubeConfigOptions) GetClusterReferences(cluster string) []string {
+	var references []string
+	for name, context := range opts.Config.Contexts {
+		if context.Cluster == cluster {
+			references = append(references, name)
+		}
+	}
+
+	sort.Strings(references)
+	return references
+}
+
+// GetAuthInfoReferences returns the sorted names of the contexts referencing the given user.
+func (opts *KubeConfigOptions) GetAuthInfoReferences(authInfo string) []string {
+	var references []string
+	for name, context := range opts.Config.Contexts {
+		if context.AuthInfo == authInfo {
+			references = append(references, name)
+		}
+	}
+
+	sort.Strings(references)
+	return references
+}
+
+// RenameCluster re-keys a cluster and updates every context referencing it.
+func (opts *KubeConfigOptions) RenameCluster(from, to string) error {
+	cluster, exists := opts.Config.Clusters[from]
+	if !exists {
+		return fmt.Errorf("cluster %q does not exist", from)
+	}
+	if _, exists := opts.Config.Clusters[to]; exists {
+		return fmt.Errorf("cluster %q already exists", to)
+	}
+
+	opts.Config.Clusters[to] = cluster
+	delete(opts.Config.Clusters, from)
+
+	for _, name := range opts.GetClusterReferences(from) {
+		opts.Config.Contexts[name].Cluster = to
+	}
+	return nil
+}
+
+// RenameAuthInfo re-keys a user and updates every context referencing it.
+func (opts *KubeConfigOptions) RenameAuthInfo(from, to string) error {
+	authInfo, exists := opts.Config.AuthInfos[from]
+	if !exists {
+		return fmt.Errorf("user %q does not exist", from)
+	}
+	if _, exists := opts.Config.AuthInfos[to]; exists {
+		return fmt.Errorf("user %q already exists", to)
+	}
+
+	opts.Config.AuthInfos[to] = authInfo
+	delete(opts.Config.AuthInfos, from)
+
+	for _, name := range opts.GetAuthInfoReferences(from) {
+		opts.Config.Contexts[name].AuthInfo = to
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+ * kube-context
+ *
+ * Copyright (C) 2023 Vincent De Borger
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"encoding/json"
+
+	"github.com/DB-Vincent/kube-context/pkg/logger"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	api "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// TagsExtension is the name of the context extension holding the tags, kubectl ignores extensions it doesn't know
+const TagsExtension = "kube-context/tags"
+
+// GetTags returns the tags of a context, or nil when it has none.
+func GetTags(context *api.Context) (map[string]string, error) {
+	extension, exists := context.Extensions[TagsExtension]
+	if !exists || extension == nil {
+		return nil, nil
+	}
+
+	unknown, ok := extension.(*runtime.Unknown)
+	if !ok {
+		return nil, fmt.Errorf("extension %q holds an unexpected %T", TagsExtension, extension)
+	}
+
+	var tags map[string]string
+	if err := json.Unmarshal(unknown.Raw, &tags); err != nil {
+		return nil, fmt.Errorf("extension %q does not hold tags: %w", TagsExtension, err)
+	}
+	return tags, nil
+}
+
+// SetTags stores the tags on a context, removing the extension entirely when there are none left.
+func SetTags(context *api.Context, tags map[string]string) error {
+	if len(tags) == 0 {
+		delete(context.Extensions, TagsExtension)
+		return nil
+	}
+
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+
+	if context.Extensions == nil {
+		context.Extensions = map[string]runtime.Object{}
+	}
+	context.Extensions[TagsExtension] = &runtime.Unknown{Raw: data, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
+
+// ParseTags parses "key=value" arguments, following the rules of Kubernetes labels so they can be used in selectors.
+func ParseTags(args []string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return nil, fmt.Errorf("tag %q is not in key=value format", arg)
+		}
+		if err := ValidateTagKey(key); err != nil {
+			return nil, err
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value for tag %q: %s", key, strings.Join(errs, "; "))
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// ValidateTagKey checks that a tag key follows the rules of Kubernetes label keys, e.g. "env" or "example.com/team".
+func ValidateTagKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid tag key %q: %s", key, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// FormatTags returns the tags as a sorted "key=value, key=value" list
+func FormatTags(tags map[string]string) string {
+	var formatted []string
+	for _, key := range SortedKeys(tags) {
+		formatted = append(formatted, key+"="+tags[key])
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// SelectContexts returns the names of the contexts whose tags match a selector in the Kubernetes label selector syntax,
+// e.g. "env=prod,team!=payments" or "cloud in (aws,gcp)".
+func (opts *KubeConfigOptions) SelectContexts(selector string) ([]string, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, name := range SortedKeys(opts.Config.Contexts) {
+		tags, err := GetTags(opts.Config.Contexts[name])
+		if err != nil {
+			logHandler.Handle(logger.ErrorType{
+				Level:   logger.Warning,
+				Message: fmt.Sprintf("Ignoring the tags of context %s: %s", name, err),
+			}, err)
+		}
+
+		if parsed.Matches(labels.Set(tags)) {
+			selected = append(selected, name)
+		}
+	}
+	return selected, nil
+}